@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"errors"
+	"sort"
 )
 
 type Action string
@@ -149,3 +150,19 @@ func (agp ActionGatePolicy) AddRule(rule *ActionGateRule) error {
 
 	return nil
 }
+
+// Returns all rules of this policy, sorted by their contexts.
+func (agp ActionGatePolicy) Rules() []*ActionGateRule {
+	keys := make([]string, 0, len(agp.rules))
+	for key := range agp.rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rules := make([]*ActionGateRule, len(keys))
+	for i, key := range keys {
+		rules[i] = agp.rules[key]
+	}
+
+	return rules
+}
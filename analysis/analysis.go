@@ -0,0 +1,185 @@
+// Package analysis statically analyzes RBAC schemas and finds rules and roles which have no effect
+// or most likely are mistakes.
+//
+// Each finding has a stable rule ID (e.g. "RBAC001"), which can be used to suppress it in the configuration:
+//
+//	"suppress": [
+//	    { "rule": "RBAC004", "target": "moderator" }
+//	]
+//
+// If target is omitted, then all findings of the rule are suppressed.
+package analysis
+
+import (
+	"sort"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+type Severity string
+
+const (
+	InfoSeverity    Severity = "info"
+	WarningSeverity Severity = "warning"
+	ErrorSeverity   Severity = "error"
+)
+
+var severityLevels = map[Severity]int{
+	InfoSeverity:    0,
+	WarningSeverity: 1,
+	ErrorSeverity:   2,
+}
+
+// Reports whether this severity is at least as high as the other one.
+func (s Severity) AtLeast(other Severity) bool {
+	return severityLevels[s] >= severityLevels[other]
+}
+
+type Rule struct {
+	ID          string
+	Name        string
+	Severity    Severity
+	Description string
+}
+
+var (
+	RedundantRequireRule = Rule{
+		ID:          "RBAC001",
+		Name:        "redundant-require",
+		Severity:    WarningSeverity,
+		Description: "\"require\" rule has no effect, since only its roles have permissions required by the action",
+	}
+	RedundantAllowRule = Rule{
+		ID:          "RBAC002",
+		Name:        "redundant-allow",
+		Severity:    WarningSeverity,
+		Description: "\"allow\" rule has no effect, since each of its roles already has permissions required by the action",
+	}
+	UnreachableDenyRule = Rule{
+		ID:          "RBAC003",
+		Name:        "unreachable-deny",
+		Severity:    InfoSeverity,
+		Description: "\"deny\" rule has no effect, since none of the roles can perform the action",
+	}
+	DuplicateRolesRule = Rule{
+		ID:          "RBAC004",
+		Name:        "duplicate-roles",
+		Severity:    WarningSeverity,
+		Description: "role has exactly the same permissions as another role",
+	}
+	ActionWithoutPermissionsRule = Rule{
+		ID:          "RBAC005",
+		Name:        "action-without-permissions",
+		Severity:    ErrorSeverity,
+		Description: "action doesn't require any permissions, so anyone can perform it",
+	}
+	EmptyDefaultRoleRule = Rule{
+		ID:          "RBAC006",
+		Name:        "empty-default-role",
+		Severity:    WarningSeverity,
+		Description: "default role doesn't grant any permissions",
+	}
+)
+
+// All rules of the analyzer, ordered by their IDs.
+var Rules = []Rule{
+	RedundantRequireRule,
+	RedundantAllowRule,
+	UnreachableDenyRule,
+	DuplicateRolesRule,
+	ActionWithoutPermissionsRule,
+	EmptyDefaultRoleRule,
+}
+
+type Finding struct {
+	Rule Rule
+	// Empty for host level findings.
+	Schema string
+	// What exactly is wrong: AGP rule context, role, "entity:action" and so on.
+	Target  string
+	Message string
+}
+
+func (f Finding) String() string {
+	location := f.Schema
+	if location == "" {
+		location = "host"
+	}
+	return location + ": " + string(f.Rule.Severity) + " " + f.Rule.ID + " [" + f.Rule.Name + "] " + f.Target + ": " + f.Message
+}
+
+func isSuppressed(finding Finding, suppressions []rbac.Suppression) bool {
+	for _, suppression := range suppressions {
+		if suppression.Rule != finding.Rule.ID && suppression.Rule != finding.Rule.Name {
+			continue
+		}
+		if suppression.Target == "" || suppression.Target == finding.Target {
+			return true
+		}
+	}
+	return false
+}
+
+func filter(findings []Finding, suppressions []rbac.Suppression) []Finding {
+	result := make([]Finding, 0, len(findings))
+
+	for _, finding := range findings {
+		if !isSuppressed(finding, suppressions) {
+			result = append(result, finding)
+		}
+	}
+
+	return result
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		if a.Rule.ID != b.Rule.ID {
+			return a.Rule.ID < b.Rule.ID
+		}
+		return a.Target < b.Target
+	})
+}
+
+// Analyzes the schema and returns all findings which are not suppressed by the schema.
+func AnalyzeSchema(schema *rbac.Schema) []Finding {
+	findings := analyzeSchema(schema)
+
+	findings = filter(findings, schema.Suppressions)
+	sortFindings(findings)
+
+	return findings
+}
+
+// Analyzes each schema of the host and the host itself.
+// Returns all findings which are not suppressed either by schema, either by host.
+func AnalyzeHost(host *rbac.Host) []Finding {
+	findings := []Finding{}
+
+	for i := range host.Schemas {
+		schema := &host.Schemas[i]
+		findings = append(findings, filter(analyzeSchema(schema), schema.Suppressions)...)
+	}
+
+	findings = append(findings, checkEmptyDefaultRoles("", host.DefaultRoles)...)
+
+	findings = filter(findings, host.Suppressions)
+	sortFindings(findings)
+
+	return findings
+}
+
+func analyzeSchema(schema *rbac.Schema) []Finding {
+	findings := []Finding{}
+
+	findings = append(findings, checkActionGatePolicy(schema)...)
+	findings = append(findings, checkDuplicateRoles(schema)...)
+	findings = append(findings, checkActionsWithoutPermissions(schema)...)
+	findings = append(findings, checkEmptyDefaultRoles(schema.ID, schema.DefaultRoles)...)
+
+	return findings
+}
@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+const testSchemaConfig = `{
+	"id": "svc",
+	"default-roles": ["guest"],
+	"roles": [
+		{"name": "guest", "permissions": {}},
+		{"name": "user", "permissions": {"self-read": true}},
+		{"name": "moderator", "permissions": {"read": true, "update": true}},
+		{"name": "editor", "permissions": {"read": true, "update": true}},
+		{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}}
+	],
+	"resources": ["cache"],
+	"entities": [{
+		"name": "user",
+		"actions": [
+			{"name": "read", "required-permissions": {"read": true}},
+			{"name": "delete", "required-permissions": {"delete": true}},
+			{"name": "create", "required-permissions": {"create": true}},
+			{"name": "ping", "required-permissions": {}}
+		]
+	}],
+	"action-gate-policy": [
+		{"for": ["user"], "having": ["admin"], "apply": "require", "doing": ["delete"], "on": "cache"},
+		{"for": ["user"], "having": ["moderator"], "apply": "allow", "doing": ["read"], "on": "cache"},
+		{"for": ["user"], "having": ["user"], "apply": "deny", "doing": ["create"], "on": "cache"}
+	]
+}`
+
+func loadTestSchema(t *testing.T, config string) rbac.Schema {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := rbac.LoadSchema(path)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	return schema
+}
+
+func TestAnalyzeSchema(t *testing.T) {
+	schema := loadTestSchema(t, testSchemaConfig)
+
+	expected := []struct {
+		rule   Rule
+		target string
+	}{
+		{RedundantRequireRule, "user:delete:cache"},
+		{RedundantAllowRule, "user:read:cache"},
+		{UnreachableDenyRule, "user:create:cache"},
+		{DuplicateRolesRule, "editor"},
+		{ActionWithoutPermissionsRule, "user:ping"},
+		{EmptyDefaultRoleRule, "guest"},
+	}
+
+	findings := AnalyzeSchema(&schema)
+
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings, got %d: %v", len(expected), len(findings), findings)
+	}
+
+	for i, finding := range findings {
+		if finding.Rule.ID != expected[i].rule.ID || finding.Target != expected[i].target {
+			t.Errorf("Expected %s on %s, got %s", expected[i].rule.ID, expected[i].target, finding)
+		}
+		if finding.Schema != "svc" {
+			t.Errorf("Expected finding in svc schema, got %s", finding.Schema)
+		}
+	}
+}
+
+func TestAnalyzeSchemaWithoutFindings(t *testing.T) {
+	schema := loadTestSchema(t, `{
+		"id": "svc",
+		"roles": [
+			{"name": "user", "permissions": {"self-read": true}},
+			{"name": "moderator", "permissions": {"read": true, "delete": true}},
+			{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}}
+		],
+		"resources": ["cache"],
+		"entities": [{"name": "user", "actions": [{"name": "delete", "required-permissions": {"delete": true}}]}],
+		"action-gate-policy": [
+			{"for": ["user"], "having": ["admin"], "apply": "require", "doing": ["delete"], "on": "cache"}
+		]
+	}`)
+
+	// Require rule actually restricts moderator, so it isn't redundant.
+	if findings := AnalyzeSchema(&schema); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	schema := loadTestSchema(t, testSchemaConfig)
+
+	schema.Suppressions = []rbac.Suppression{
+		{Rule: "RBAC004", Target: "editor"},
+		{Rule: "empty-default-role"},
+		{Rule: "RBAC001", Target: "user:read:cache"},
+	}
+
+	findings := AnalyzeSchema(&schema)
+	for _, finding := range findings {
+		if finding.Rule.ID == DuplicateRolesRule.ID || finding.Rule.ID == EmptyDefaultRoleRule.ID {
+			t.Errorf("Finding should be suppressed: %s", finding)
+		}
+	}
+
+	found := false
+	for _, finding := range findings {
+		if finding.Rule.ID == RedundantRequireRule.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Suppression with other target must not suppress finding")
+	}
+}
+
+func TestAnalyzeHost(t *testing.T) {
+	schema := loadTestSchema(t, testSchemaConfig)
+	host := rbac.Host{
+		DefaultRoles: []rbac.Role{rbac.NewRole("nobody", 0)},
+		Schemas:      []rbac.Schema{schema},
+		Suppressions: []rbac.Suppression{{Rule: "RBAC005"}},
+	}
+
+	findings := AnalyzeHost(&host)
+
+	if findings[0].Schema != "" || findings[0].Rule.ID != EmptyDefaultRoleRule.ID || findings[0].Target != "nobody" {
+		t.Errorf("Expected host level finding first, got %s", findings[0])
+	}
+	if findings[0].String() != "host: warning RBAC006 [empty-default-role] nobody: default role doesn't grant any permissions" {
+		t.Errorf("Unexpected string representation: %s", findings[0])
+	}
+
+	for _, finding := range findings {
+		if finding.Rule.ID == ActionWithoutPermissionsRule.ID {
+			t.Errorf("Finding should be suppressed by host: %s", finding)
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	if !ErrorSeverity.AtLeast(WarningSeverity) || WarningSeverity.AtLeast(ErrorSeverity) || !InfoSeverity.AtLeast(InfoSeverity) {
+		t.Error("Unexpected severity ordering")
+	}
+}
+
+func TestAnalyzeSchemaMatchStrategies(t *testing.T) {
+	schema := loadTestSchema(t, `{
+		"id": "svc",
+		"roles": [
+			{"name": "reader", "permissions": {"read": true}},
+			{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}}
+		],
+		"resources": ["cache", "feed"],
+		"entities": [{"name": "post", "match": "any", "actions": [
+			{"name": "edit", "required-permissions": {"read": true, "update": true}},
+			{"name": "ping", "required-permissions": {}}
+		]}],
+		"action-gate-policy": [
+			{"for": ["post"], "having": ["reader"], "apply": "deny", "doing": ["edit"], "on": "cache"},
+			{"for": ["post"], "having": ["reader"], "apply": "allow", "doing": ["edit"], "on": "feed"},
+			{"for": ["post"], "having": ["reader"], "apply": "deny", "doing": ["ping"], "on": "cache"}
+		]
+	}`)
+
+	// With "any" matching reader can edit post, so deny rule is reachable and allow rule is redundant.
+	findings := AnalyzeSchema(&schema)
+	if len(findings) != 2 || findings[0].Rule.ID != RedundantAllowRule.ID || findings[1].Rule.ID != ActionWithoutPermissionsRule.ID {
+		t.Fatalf("Expected RBAC002 and RBAC005 findings, got %v", findings)
+	}
+
+	// Result of the custom strategy is unknown, so its rules and actions aren't checked.
+	schema.Entities[0].SetStrategy(func(ctx *rbac.AuthorizationContext, roles []rbac.Role) error {
+		return nil
+	})
+	if findings := AnalyzeSchema(&schema); len(findings) != 0 {
+		t.Errorf("Expected no findings for custom strategy, got %v", findings)
+	}
+}
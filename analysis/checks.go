@@ -0,0 +1,163 @@
+package analysis
+
+import (
+	"strings"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+func ruleTarget(rule *rbac.ActionGateRule) string {
+	return rule.Entity.Name() + ":" + rule.Action.String() + ":" + rule.Resource.Name()
+}
+
+func hasRole(roles []rbac.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether permitted permissions are sufficient for the action with the specified match strategy.
+func covers(permitted rbac.Permissions, required rbac.Permissions, match rbac.MatchStrategy) bool {
+	if match == rbac.AnyMatchStrategy {
+		return required == 0 || required&permitted != 0
+	}
+	return required&permitted == required
+}
+
+func checkActionGatePolicy(schema *rbac.Schema) []Finding {
+	var findings []Finding
+
+	var allPermissions rbac.Permissions
+	for _, role := range schema.Roles {
+		allPermissions |= role.Permissions
+	}
+
+	for _, rule := range schema.ActionGatePolicy.Rules() {
+		required, ok := rule.Entity.GetRequiredActionPermissions(rule.Action)
+		if !ok {
+			continue
+		}
+
+		// Result of the custom strategy can't be predicted statically.
+		match := rule.Entity.EffectiveMatch(rule.Action)
+		if match == "" {
+			continue
+		}
+
+		target := ruleTarget(rule)
+
+		switch rule.Effect {
+		case rbac.RequireActionGateEffect:
+			// If other roles can't pass authorization even combined, then any role set
+			// which has required permissions already contains one of the rule roles.
+			var otherPermissions rbac.Permissions
+			for _, role := range schema.Roles {
+				if !hasRole(rule.Roles, role.Name) {
+					otherPermissions |= role.Permissions
+				}
+			}
+			if !covers(otherPermissions, required, match) {
+				findings = append(findings, Finding{
+					Rule:    RedundantRequireRule,
+					Schema:  schema.ID,
+					Target:  target,
+					Message: "only required roles (" + strings.Join(rbac.GetRolesNames(rule.Roles), ", ") + ") have permissions for this action",
+				})
+			}
+		case rbac.AllowActionGateEffect:
+			redundant := true
+			for _, role := range rule.Roles {
+				if !covers(role.Permissions, required, match) {
+					redundant = false
+					break
+				}
+			}
+			if redundant {
+				findings = append(findings, Finding{
+					Rule:    RedundantAllowRule,
+					Schema:  schema.ID,
+					Target:  target,
+					Message: "roles (" + strings.Join(rbac.GetRolesNames(rule.Roles), ", ") + ") are already permitted to perform this action",
+				})
+			}
+		case rbac.DenyActionGateEffect:
+			if !covers(allPermissions, required, match) {
+				findings = append(findings, Finding{
+					Rule:    UnreachableDenyRule,
+					Schema:  schema.ID,
+					Target:  target,
+					Message: "none of the schema roles can perform this action",
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+func checkDuplicateRoles(schema *rbac.Schema) []Finding {
+	var findings []Finding
+
+	firstRoles := map[rbac.Permissions]string{}
+
+	for _, role := range schema.Roles {
+		first, ok := firstRoles[role.Permissions]
+		if !ok {
+			firstRoles[role.Permissions] = role.Name
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Rule:    DuplicateRolesRule,
+			Schema:  schema.ID,
+			Target:  role.Name,
+			Message: "role has the same permissions as \"" + first + "\" role",
+		})
+	}
+
+	return findings
+}
+
+func checkActionsWithoutPermissions(schema *rbac.Schema) []Finding {
+	var findings []Finding
+
+	for _, entity := range schema.Entities {
+		for _, act := range entity.Actions() {
+			// Action with custom strategy may be authorized without permissions.
+			if required, _ := entity.GetRequiredActionPermissions(act); required != 0 || entity.EffectiveMatch(act) == "" {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Rule:    ActionWithoutPermissionsRule,
+				Schema:  schema.ID,
+				Target:  entity.Name() + ":" + act.String(),
+				Message: "action doesn't require any permissions",
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkEmptyDefaultRoles(schemaID string, defaultRoles []rbac.Role) []Finding {
+	var findings []Finding
+
+	for _, role := range defaultRoles {
+		if role.Permissions != 0 {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Rule:    EmptyDefaultRoleRule,
+			Schema:  schemaID,
+			Target:  role.Name,
+			Message: "default role doesn't grant any permissions",
+		})
+	}
+
+	return findings
+}
@@ -4,25 +4,31 @@ package rbac
 type AuthzFunc func(Permissions, Permissions) error
 
 // RuleProvider can lookup ActionGate rules for provided context.
+// Providers can be combined via ChainProviders and AllProviders.
 type RuleProvider interface {
 	GetRule(ctx *AuthorizationContext) (*ActionGateRule, bool)
 }
 
 // Authorizer encapsulates authorization behavior.
 type Authorizer struct {
-	authzFunc AuthzFunc
+	authzFunc     AuthzFunc
+	relations     *RelationChecker
+	interceptors  []Interceptor
+	failurePolicy FailurePolicy
 }
 
-// NewAuthorizer creates authorizer with default authorization function.
+// NewAuthorizer creates authorizer with default authorization function, which fails closed (see FailurePolicy).
 func NewAuthorizer() *Authorizer {
 	return &Authorizer{
-		authzFunc: AuthorizeCRUDFunc,
+		authzFunc:     AuthorizeCRUDFunc,
+		failurePolicy: FailClosedPolicy,
 	}
 }
 
 var defaultAuthorizer = NewAuthorizer()
 
 // SetAuthzFunc overrides default authorization function globally.
+// Strategies of the entities and actions take precedence over it (see Entity.SetActionStrategy).
 func SetAuthzFunc(fn AuthzFunc) {
 	defaultAuthorizer.SetAuthzFunc(fn)
 }
@@ -35,6 +41,18 @@ func (a *Authorizer) SetAuthzFunc(fn AuthzFunc) {
 	a.authzFunc = fn
 }
 
+// SetRelationChecker enables relation based authorization globally.
+func SetRelationChecker(checker *RelationChecker) {
+	defaultAuthorizer.SetRelationChecker(checker)
+}
+
+// SetRelationChecker enables relation based authorization for this authorizer.
+// If roles are not sufficient to perform an action, then it may be still granted through relation.
+// Pass nil to disable it.
+func (a *Authorizer) SetRelationChecker(checker *RelationChecker) {
+	a.relations = checker
+}
+
 // Checks if the "permitted" permissions are sufficient to satisfy the "required" CRUD permissions.
 //
 // It returns an "InsufficientPermissions" error if any of the "required" permissions are not covered by the "permitted" permissions.
@@ -60,32 +78,5 @@ func Authorize(ctx *AuthorizationContext, roles []Role, provider RuleProvider) e
 
 // Authorize checks authorization using provided rule provider.
 func (a *Authorizer) Authorize(ctx *AuthorizationContext, roles []Role, provider RuleProvider) error {
-	if !ctx.Entity.HasAction(ctx.Action) {
-		return ErrEntityDoesNotHaveSuchAction
-	}
-
-	if provider != nil {
-		if rule, ok := provider.GetRule(ctx); ok {
-			bypass, err := rule.Apply(ctx.Action, roles)
-			if err != nil {
-				return err
-			}
-			if bypass {
-				return nil
-			}
-		}
-	}
-
-	requiredPermissions := ctx.Entity.actions[ctx.Action]
-	mergredPermissions := Permissions(0)
-
-	for _, role := range roles {
-		mergredPermissions |= role.Permissions
-	}
-
-	if err := a.authzFunc(requiredPermissions, mergredPermissions); err != nil {
-		return err
-	}
-
-	return nil
+	return a.Decide(ctx, roles, provider).Err
 }
@@ -116,13 +116,13 @@ func TestAuthorizeWithActionGatePolicy(t *testing.T) {
 
 	// Test deny effect
 	err := Authorize(&ctx, []Role{adminRole}, &agp)
-	if err != ErrActionDeniedByAGP {
+	if !errors.Is(err, ErrActionDeniedByAGP) {
 		t.Errorf("Expected ActionDeniedByAGP, got %v", err)
 	}
 
 	// Test no rule applies
 	err = Authorize(&ctx, []Role{userRole}, &agp)
-	if err != ErrInsufficientPermissions {
+	if !errors.Is(err, ErrInsufficientPermissions) {
 		t.Errorf("Expected InsufficientPermissions, got %v", err)
 	}
 }
@@ -0,0 +1,68 @@
+package rbac
+
+// ActionRequest is a single action on the resource, which must be authorized as a part of the batch (see AuthorizeMany).
+type ActionRequest struct {
+	Action   Action
+	Resource *Resource
+}
+
+// Authorizes each request of the entity with the same roles. Permissions of the roles are merged only once.
+// Returns errors in the same order as requests, error is nil if request is allowed.
+func AuthorizeMany(entity *Entity, requests []ActionRequest, roles []Role, provider RuleProvider) []error {
+	return defaultAuthorizer.AuthorizeMany(entity, requests, roles, provider)
+}
+
+// Same as AuthorizeMany, but returns detailed results of authorization.
+func DecideMany(entity *Entity, requests []ActionRequest, roles []Role, provider RuleProvider) []Decision {
+	return defaultAuthorizer.DecideMany(entity, requests, roles, provider)
+}
+
+// Returns all actions of the entity, which roles are allowed to perform on the resource,
+// with effects of the rule provider (e.g. Action Gate Policy) applied. Actions are in alphabetical order.
+func AllowedActions(entity *Entity, resource *Resource, roles []Role, provider RuleProvider) []Action {
+	return defaultAuthorizer.AllowedActions(entity, resource, roles, provider)
+}
+
+// AuthorizeMany checks authorization of each request using provided rule provider, see AuthorizeMany.
+func (a *Authorizer) AuthorizeMany(entity *Entity, requests []ActionRequest, roles []Role, provider RuleProvider) []error {
+	decisions := a.DecideMany(entity, requests, roles, provider)
+
+	errs := make([]error, len(decisions))
+	for i, decision := range decisions {
+		errs[i] = decision.Err
+	}
+
+	return errs
+}
+
+// DecideMany checks authorization of each request using provided rule provider and returns detailed results of it.
+func (a *Authorizer) DecideMany(entity *Entity, requests []ActionRequest, roles []Role, provider RuleProvider) []Decision {
+	merged := mergePermissions(roles)
+
+	decisions := make([]Decision, len(requests))
+	for i, request := range requests {
+		ctx := NewAuthorizationContext(entity, request.Action, request.Resource)
+		decisions[i] = a.decideMerged(&ctx, roles, merged, provider)
+	}
+
+	return decisions
+}
+
+// AllowedActions returns all actions of the entity which are allowed on the resource, see AllowedActions.
+func (a *Authorizer) AllowedActions(entity *Entity, resource *Resource, roles []Role, provider RuleProvider) []Action {
+	actions := entity.Actions()
+
+	requests := make([]ActionRequest, len(actions))
+	for i, act := range actions {
+		requests[i] = ActionRequest{Action: act, Resource: resource}
+	}
+
+	var allowed []Action
+	for i, decision := range a.DecideMany(entity, requests, roles, provider) {
+		if decision.Allowed {
+			allowed = append(allowed, actions[i])
+		}
+	}
+
+	return allowed
+}
@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizeMany(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+	db := NewResource("db")
+
+	restricted := NewRole("restricted", ReadPermission|DeletePermission)
+	support := NewRole("support", ReadPermission)
+
+	agp := NewActionGatePolicy()
+	deleteDBCtx := NewAuthorizationContext(&user, deleteAction, db)
+	if err := agp.AddRule(NewActionGateRule(&deleteDBCtx, DenyActionGateEffect, []Role{restricted})); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []ActionRequest{
+		{readAction, cache},
+		{deleteAction, cache},
+		{deleteAction, db},
+		{"purge", db},
+	}
+
+	tests := []struct {
+		name     string
+		roles    []Role
+		expected []error
+	}{
+		{"restricted", []Role{restricted}, []error{nil, nil, ErrActionDeniedByAGP, ErrEntityDoesNotHaveSuchAction}},
+		{"support", []Role{support}, []error{nil, ErrInsufficientPermissions, ErrInsufficientPermissions, ErrEntityDoesNotHaveSuchAction}},
+		{"no roles", nil, []error{ErrInsufficientPermissions, ErrInsufficientPermissions, ErrInsufficientPermissions, ErrEntityDoesNotHaveSuchAction}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := AuthorizeMany(&user, requests, tt.roles, agp)
+			if len(errs) != len(requests) {
+				t.Fatalf("Expected %d results, got %d", len(requests), len(errs))
+			}
+
+			for i, err := range errs {
+				if !errors.Is(err, tt.expected[i]) {
+					t.Errorf("Request %d: expected %v, got %v", i, tt.expected[i], err)
+				}
+
+				// Batch must give the same results as separate calls.
+				ctx := NewAuthorizationContext(&user, requests[i].Action, requests[i].Resource)
+				if single := Decide(&ctx, tt.roles, agp); !reflect.DeepEqual(single, DecideMany(&user, requests, tt.roles, agp)[i]) {
+					t.Errorf("Request %d: batch decision differs from the single one", i)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowedActions(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	user.NewAction("delete", DeletePermission)
+	user.NewAction("ping", 0)
+	cache := NewResource("cache")
+
+	support := NewRole("support", ReadPermission)
+	admin := NewRole("admin", ReadPermission|DeletePermission)
+	suspended := NewRole("suspended", 0)
+
+	agp := NewActionGatePolicy()
+	readCtx := NewAuthorizationContext(&user, readAction, cache)
+	if err := agp.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{suspended})); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		roles    []Role
+		expected []Action
+	}{
+		{"support", []Role{support}, []Action{"ping", "read"}},
+		{"admin", []Role{admin}, []Action{"delete", "ping", "read"}},
+		{"denied by AGP", []Role{admin, suspended}, []Action{"delete", "ping"}},
+		{"no roles", nil, []Action{"ping"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := AllowedActions(&user, cache, tt.roles, agp); !reflect.DeepEqual(allowed, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, allowed)
+			}
+		})
+	}
+}
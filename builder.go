@@ -0,0 +1,217 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ActionDefinition describes an action of the entity, which is created by the SchemaBuilder.
+type ActionDefinition struct {
+	Name                string
+	RequiredPermissions Permissions
+	// Match strategy of the action, strategy of the entity is used if it's empty (see MatchStrategy).
+	Match MatchStrategy
+}
+
+// Shorthand for the ActionDefinition.
+func Act(name string, requiredPermissions Permissions) ActionDefinition {
+	return ActionDefinition{
+		Name:                name,
+		RequiredPermissions: requiredPermissions,
+	}
+}
+
+// Same as Act, but action is allowed if roles have at least one of the required permissions.
+func ActAny(name string, requiredPermissions Permissions) ActionDefinition {
+	return ActionDefinition{
+		Name:                name,
+		RequiredPermissions: requiredPermissions,
+		Match:               AnyMatchStrategy,
+	}
+}
+
+// SchemaBuilder builds Schema step by step:
+//
+//	schema, err := rbac.Build("auth-service").
+//		Role("user", rbac.SelfReadPermission).
+//		Role("admin", rbac.ReadPermission|rbac.DeletePermission).
+//		DefaultRoles("user").
+//		Entity("user", rbac.Act("delete", rbac.DeletePermission)).
+//		Resource("cache").
+//		Require("user:delete:cache", "admin").
+//		Done()
+//
+// Order of the calls doesn't matter, references to roles, entities, actions and resources
+// are resolved only when Done is called.
+// Errors are collected along the way and returned by Done all at once.
+type SchemaBuilder struct {
+	id           string
+	roles        []Role
+	defaultRoles []string
+	entities     []Entity
+	resources    []Resource
+	rules        []*rawActionGateRules
+	errs         []error
+}
+
+// Starts building of the schema with the specified ID.
+func Build(id string) *SchemaBuilder {
+	b := &SchemaBuilder{id: id}
+	if id == "" {
+		b.errs = append(b.errs, errors.New("Schema ID is missing"))
+	}
+	return b
+}
+
+// Adds role with the specified permissions.
+func (b *SchemaBuilder) Role(name string, permissions Permissions) *SchemaBuilder {
+	for _, role := range b.roles {
+		if role.Name == name {
+			b.errs = append(b.errs, fmt.Errorf("Role %s is duplicated in the %s schema", name, b.id))
+			return b
+		}
+	}
+
+	b.roles = append(b.roles, NewRole(name, permissions))
+
+	return b
+}
+
+// Adds roles with the specified names to the schema default roles.
+func (b *SchemaBuilder) DefaultRoles(names ...string) *SchemaBuilder {
+	b.defaultRoles = append(b.defaultRoles, names...)
+	return b
+}
+
+// Adds entity with the specified actions.
+func (b *SchemaBuilder) Entity(name string, actions ...ActionDefinition) *SchemaBuilder {
+	if _, ok := b.entity(name); ok {
+		b.errs = append(b.errs, fmt.Errorf("Entity %s is duplicated in the %s schema", name, b.id))
+		return b
+	}
+
+	entity := NewEntity(name)
+
+	for _, def := range actions {
+		act, err := entity.NewAction(def.Name, def.RequiredPermissions)
+		if err != nil {
+			b.errs = append(b.errs, err)
+			continue
+		}
+		if def.Match != "" {
+			if err := entity.SetActionMatch(act, def.Match); err != nil {
+				b.errs = append(b.errs, err)
+			}
+		}
+	}
+
+	b.entities = append(b.entities, entity)
+
+	return b
+}
+
+// Adds resources with the specified names.
+func (b *SchemaBuilder) Resource(names ...string) *SchemaBuilder {
+	for _, name := range names {
+		if b.hasResource(name) {
+			b.errs = append(b.errs, fmt.Errorf("Resource %s is duplicated in the %s schema", name, b.id))
+			continue
+		}
+		b.resources = append(b.resources, *NewResource(name))
+	}
+
+	return b
+}
+
+// Adds Action Gate Policy rule which denies the context for the specified roles (at least one is required).
+// Context must be in the "entity:action:resource" form.
+func (b *SchemaBuilder) Deny(context string, roles ...string) *SchemaBuilder {
+	return b.rule(DenyActionGateEffect, context, roles)
+}
+
+// Adds Action Gate Policy rule which permits the context only if one of the specified roles is present.
+// Context must be in the "entity:action:resource" form.
+func (b *SchemaBuilder) Require(context string, roles ...string) *SchemaBuilder {
+	return b.rule(RequireActionGateEffect, context, roles)
+}
+
+// Adds Action Gate Policy rule which permits the context for the specified roles bypassing permissions check.
+// Context must be in the "entity:action:resource" form.
+func (b *SchemaBuilder) Allow(context string, roles ...string) *SchemaBuilder {
+	return b.rule(AllowActionGateEffect, context, roles)
+}
+
+func (b *SchemaBuilder) rule(effect ActionGateEffect, context string, roles []string) *SchemaBuilder {
+	parts := strings.Split(context, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		b.errs = append(b.errs, fmt.Errorf("Invalid %s rule context \"%s\" - expected \"entity:action:resource\"", effect, context))
+		return b
+	}
+
+	b.rules = append(b.rules, &rawActionGateRules{
+		For:    []string{parts[0]},
+		Having: roles,
+		Apply:  string(effect),
+		Doing:  []string{parts[1]},
+		On:     parts[2],
+	})
+
+	return b
+}
+
+func (b *SchemaBuilder) entity(name string) (Entity, bool) {
+	for _, entity := range b.entities {
+		if entity.name == name {
+			return entity, true
+		}
+	}
+	return Entity{}, false
+}
+
+func (b *SchemaBuilder) hasResource(name string) bool {
+	for _, resource := range b.resources {
+		if resource.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds and validates the schema.
+// Returns all errors which were detected during the building, joined via errors.Join.
+func (b *SchemaBuilder) Done() (Schema, error) {
+	errs := append([]error{}, b.errs...)
+
+	defaultRoles, err := normalizeDefaultRoles(b.roles, b.defaultRoles)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Invalid default roles of the %s schema: %s", b.id, err.Error()))
+	}
+
+	// Each rule is normalized separately to report all invalid rules, not only the first one.
+	for _, rule := range b.rules {
+		_, err := normalizeActionGatePolicy(b.entities, b.roles, b.resources, []*rawActionGateRules{rule})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid %s rule %s:%s:%s: %s", rule.Apply, rule.For[0], rule.Doing[0], rule.On, err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return Schema{}, errors.Join(errs...)
+	}
+
+	agp, err := normalizeActionGatePolicy(b.entities, b.roles, b.resources, b.rules)
+	if err != nil {
+		return Schema{}, fmt.Errorf("Failed to normalize Action Gate Policy for the %s schema: %s", b.id, err.Error())
+	}
+
+	schema := NewSchema(b.id, b.roles, defaultRoles, agp)
+	schema.Entities = b.entities
+	schema.Resources = b.resources
+
+	if err := ValidateSchema(&schema); err != nil {
+		return Schema{}, err
+	}
+
+	return schema, nil
+}
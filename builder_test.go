@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchemaBuilder(t *testing.T) {
+	schema, err := Build("svc").
+		Role("user", SelfReadPermission).
+		Role("moderator", ReadPermission|DeletePermission).
+		Role("admin", ReadPermission|UpdatePermission|DeletePermission).
+		DefaultRoles("user").
+		Entity("user", Act("read", ReadPermission), Act("delete", DeletePermission)).
+		Resource("cache", "database").
+		Deny("user:delete:cache", "moderator").
+		Require("user:delete:database", "admin").
+		Done()
+	if err != nil {
+		t.Fatalf("Failed to build schema: %v", err)
+	}
+
+	if len(schema.Roles) != 3 || len(schema.DefaultRoles) != 1 || len(schema.Entities) != 1 || len(schema.Resources) != 2 {
+		t.Fatalf("Unexpected schema: %+v", schema)
+	}
+	if len(schema.ActionGatePolicy.Rules()) != 2 {
+		t.Errorf("Expected 2 rules, got %d", len(schema.ActionGatePolicy.Rules()))
+	}
+
+	moderator, _ := schema.ParseRole("moderator")
+	ctx := NewAuthorizationContext(&schema.Entities[0], "delete", &schema.Resources[0])
+
+	if err := Authorize(&ctx, []Role{moderator}, schema.Policy()); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected ErrActionDeniedByAGP, got %v", err)
+	}
+}
+
+func TestSchemaBuilderErrors(t *testing.T) {
+	_, err := Build("svc").
+		Role("user", SelfReadPermission).
+		Role("user", ReadPermission).
+		DefaultRoles("guest").
+		Entity("user", Act("read", ReadPermission), Act("read", DeletePermission)).
+		Resource("cache").
+		Deny("user:delete:cache").
+		Require("user:read:database", "user").
+		Allow("user:read").
+		Done()
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+
+	expected := []string{
+		"Role user is duplicated",
+		"already has \"read\" action",
+		"Invalid allow rule context \"user:read\"",
+		"Invalid default roles",
+		"Invalid deny rule user:delete:cache",
+		"Invalid require rule user:read:database",
+	}
+
+	for _, s := range expected {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected error to contain %q, got:\n%v", s, err)
+		}
+	}
+
+	if _, err := Build("").Done(); err == nil {
+		t.Error("Expected error for missing schema ID")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+// Converts configuration between formats, which are determined by the file extensions.
+func convert(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	input, output := args[0], args[1]
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	result, err := rbac.ConvertConfig(data, rbac.ConfigFormatFromPath(input), rbac.ConfigFormatFromPath(output))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, result, 0o644)
+}
+
+// Formats DSL files, prints result to the stdout or overwrites files if -w flag is set.
+func formatDSL(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return errUsage
+	}
+
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := rbac.FormatDSL(data)
+		if err != nil {
+			return errors.New(path + ": " + err.Error())
+		}
+
+		if *write {
+			err = os.WriteFile(path, formatted, 0o644)
+		} else {
+			_, err = os.Stdout.Write(formatted)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
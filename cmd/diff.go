@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+	"github.com/abaxoth0/SentinelRBAC/diff"
+)
+
+func diffHosts(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errUsage
+	}
+
+	oldHost, err := rbac.LoadHost(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	newHost, err := rbac.LoadHost(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	d := diff.Hosts(&oldHost, &newHost)
+
+	switch *format {
+	case "text":
+		return diff.WriteText(os.Stdout, d)
+	case "json":
+		return diff.WriteJSON(os.Stdout, d)
+	default:
+		return errors.New("unknown diff format \"" + *format + "\"")
+	}
+}
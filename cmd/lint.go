@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/abaxoth0/SentinelRBAC/analysis"
+)
+
+func lint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	failOn := flags.String("fail-on", "warning", "minimal severity of findings which fails linting: info, warning or error")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	threshold := analysis.Severity(*failOn)
+	switch threshold {
+	case analysis.InfoSeverity, analysis.WarningSeverity, analysis.ErrorSeverity:
+	default:
+		return errors.New("unknown severity \"" + *failOn + "\"")
+	}
+
+	host, err := loadHost()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, finding := range analysis.AnalyzeHost(&host) {
+		fmt.Println(finding.String())
+
+		if finding.Rule.Severity.AtLeast(threshold) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return errors.New(strconv.Itoa(failed) + " finding(-s) with severity " + *failOn + " or higher")
+	}
+
+	return nil
+}
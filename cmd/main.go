@@ -1,15 +1,119 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"sort"
 
 	rbac "github.com/abaxoth0/SentinelRBAC"
 )
 
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"who-can": {
+		usage: "who-can <schema> <entity> <action> <resource>",
+		run:   whoCan,
+	},
+	"convert": {
+		usage: "convert <input file> <output file> (.json, .yaml, .yml or .rbac)",
+		run:   convert,
+	},
+	"diff": {
+		usage: "diff [-format text|json] <old config> <new config>",
+		run:   diffHosts,
+	},
+	"fmt": {
+		usage: "fmt [-w] <file> [file...]",
+		run:   formatDSL,
+	},
+	"lint": {
+		usage: "lint [-fail-on info|warning|error]",
+		run:   lint,
+	},
+	"report": {
+		usage: "report [-format md|csv|html] [schema]",
+		run:   generateReport,
+	},
+	"test": {
+		usage: "test <suite file> [suite file...]",
+		run:   runTests,
+	},
+	"what-can": {
+		usage: "what-can <schema> <role> [role...]",
+		run:   whatCan,
+	},
+}
+
+// Must be returned by command if it received invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+var (
+	configPath = flag.String("config", "RBAC.json", "path to the RBAC host configuration file")
+	debug      = flag.Bool("debug", false, "enable debug logs")
+	env        = flag.String("env", "", "environment which overlay must be applied to the configuration (e.g. RBAC.prod.json)")
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: rbac [flags] [command] [args]")
+	fmt.Fprintln(os.Stderr, "\nIf command is omitted, then runs an example.")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func loadHost() (rbac.Host, error) {
+	return rbac.LoadHostOptions(*configPath, rbac.LoadOptions{Env: *env})
+}
+
 func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	rbac.Debug.Enabled = *debug
+
+	if flag.NArg() == 0 {
+		runExample()
+		return
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unknown command: "+flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(flag.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "Usage: rbac [flags] "+cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func runExample() {
 	rbac.Debug.Enabled = true
 
-	_, err := rbac.LoadHost("RBAC.json")
+	_, err := loadHost()
 
 	if err != nil {
 		println("hit (from main.go)")
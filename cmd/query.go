@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+func whoCan(args []string) error {
+	if len(args) != 4 {
+		return errUsage
+	}
+
+	host, err := loadHost()
+	if err != nil {
+		return err
+	}
+
+	combinations, err := host.WhoCan(args[0], args[1], rbac.Action(args[2]), args[3])
+	if err != nil {
+		return err
+	}
+
+	if len(combinations) == 0 {
+		fmt.Println("Nobody can " + strings.Join(args[1:], ":"))
+		return nil
+	}
+
+	for _, roles := range combinations {
+		fmt.Println(strings.Join(rbac.GetRolesNames(roles), " + "))
+	}
+
+	return nil
+}
+
+func whatCan(args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	host, err := loadHost()
+	if err != nil {
+		return err
+	}
+
+	permitted, err := host.WhatCan(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+
+	for _, p := range permitted {
+		fmt.Println(p.String())
+	}
+
+	return nil
+}
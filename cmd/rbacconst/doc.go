@@ -0,0 +1,4 @@
+// Package rbacconst is an example of the code generated by rbacgen from the RBAC.json.
+package rbacconst
+
+//go:generate go run ../rbacgen -config ../RBAC.json -package rbacconst -out rbac_gen.go
@@ -0,0 +1,140 @@
+// Code generated by rbacgen. DO NOT EDIT.
+// Source: ../RBAC.json
+
+package rbacconst
+
+import rbac "github.com/abaxoth0/SentinelRBAC"
+
+func newEntity(name string, actions map[rbac.Action]rbac.Permissions) *rbac.Entity {
+	entity := rbac.NewEntity(name)
+	for act, permissions := range actions {
+		if _, err := entity.NewAction(act.String(), permissions); err != nil {
+			panic(err)
+		}
+	}
+	return &entity
+}
+
+// Schema "auth-service"
+
+const AuthServiceSchemaID = "auth-service"
+
+// Names of the "auth-service" schema roles.
+const (
+	AuthServiceUnconfirmedUserRoleName = "unconfirmed_user"
+	AuthServiceRestrictedUserRoleName  = "restricted_user"
+	AuthServiceUserRoleName            = "user"
+	AuthServiceSupportRoleName         = "support"
+	AuthServiceModeratorRoleName       = "moderator"
+	AuthServiceAdminRoleName           = "admin"
+)
+
+// Names of the "auth-service" schema resources.
+const (
+	AuthServiceCacheResourceName = "cache"
+	AuthServiceUserResourceName  = "user"
+)
+
+// Actions of the "service" entity.
+const (
+	AuthServiceServiceReadAction rbac.Action = "read"
+)
+
+// Actions of the "user" entity.
+const (
+	AuthServiceUserChangePasswordAction rbac.Action = "change-password"
+	AuthServiceUserDeleteAction         rbac.Action = "delete"
+	AuthServiceUserSelfDeleteAction     rbac.Action = "self-delete"
+)
+
+// AuthServiceResource is a resource of the "auth-service" schema.
+type AuthServiceResource struct {
+	*rbac.Resource
+}
+
+// AuthServiceAction is an action of the "auth-service" schema entity.
+type AuthServiceAction struct {
+	Entity *rbac.Entity
+	Action rbac.Action
+}
+
+// On returns authorization context of this action on the given resource.
+func (a AuthServiceAction) On(resource AuthServiceResource) rbac.AuthorizationContext {
+	return rbac.NewAuthorizationContext(a.Entity, a.Action, resource.Resource)
+}
+
+type authServiceSchemaRoles struct {
+	UnconfirmedUser rbac.Role
+	RestrictedUser  rbac.Role
+	User            rbac.Role
+	Support         rbac.Role
+	Moderator       rbac.Role
+	Admin           rbac.Role
+}
+
+type authServiceSchemaResources struct {
+	Cache AuthServiceResource
+	User  AuthServiceResource
+}
+
+type authServiceSchemaServiceEntity struct {
+	Entity *rbac.Entity
+	Read   AuthServiceAction
+}
+
+type authServiceSchemaUserEntity struct {
+	Entity         *rbac.Entity
+	ChangePassword AuthServiceAction
+	Delete         AuthServiceAction
+	SelfDelete     AuthServiceAction
+}
+
+type authServiceSchema struct {
+	ID           string
+	Roles        authServiceSchemaRoles
+	DefaultRoles []rbac.Role
+	Resources    authServiceSchemaResources
+	Service      authServiceSchemaServiceEntity
+	User         authServiceSchemaUserEntity
+}
+
+// AuthService contains ready-built values of the "auth-service" schema.
+var AuthService = func() authServiceSchema {
+	entity0 := newEntity("service", map[rbac.Action]rbac.Permissions{
+		AuthServiceServiceReadAction: rbac.ReadPermission,
+	})
+	entity1 := newEntity("user", map[rbac.Action]rbac.Permissions{
+		AuthServiceUserChangePasswordAction: rbac.UpdatePermission,
+		AuthServiceUserDeleteAction:         rbac.DeletePermission,
+		AuthServiceUserSelfDeleteAction:     rbac.SelfDeletePermission,
+	})
+
+	return authServiceSchema{
+		ID: AuthServiceSchemaID,
+		Roles: authServiceSchemaRoles{
+			UnconfirmedUser: rbac.NewRole(AuthServiceUnconfirmedUserRoleName, rbac.SelfReadPermission),
+			RestrictedUser:  rbac.NewRole(AuthServiceRestrictedUserRoleName, rbac.SelfReadPermission|rbac.SelfDeletePermission),
+			User:            rbac.NewRole(AuthServiceUserRoleName, rbac.SelfReadPermission|rbac.SelfUpdatePermission|rbac.SelfDeletePermission),
+			Support:         rbac.NewRole(AuthServiceSupportRoleName, rbac.ReadPermission|rbac.SelfUpdatePermission),
+			Moderator:       rbac.NewRole(AuthServiceModeratorRoleName, rbac.CreatePermission|rbac.SelfCreatePermission|rbac.ReadPermission|rbac.SelfReadPermission|rbac.UpdatePermission|rbac.SelfUpdatePermission|rbac.DeletePermission|rbac.SelfDeletePermission),
+			Admin:           rbac.NewRole(AuthServiceAdminRoleName, rbac.CreatePermission|rbac.SelfCreatePermission|rbac.ReadPermission|rbac.SelfReadPermission|rbac.UpdatePermission|rbac.SelfUpdatePermission|rbac.DeletePermission|rbac.SelfDeletePermission),
+		},
+		DefaultRoles: []rbac.Role{
+			rbac.NewRole(AuthServiceAdminRoleName, rbac.CreatePermission|rbac.SelfCreatePermission|rbac.ReadPermission|rbac.SelfReadPermission|rbac.UpdatePermission|rbac.SelfUpdatePermission|rbac.DeletePermission|rbac.SelfDeletePermission),
+		},
+		Resources: authServiceSchemaResources{
+			Cache: AuthServiceResource{rbac.NewResource(AuthServiceCacheResourceName)},
+			User:  AuthServiceResource{rbac.NewResource(AuthServiceUserResourceName)},
+		},
+		Service: authServiceSchemaServiceEntity{
+			Entity: entity0,
+			Read:   AuthServiceAction{entity0, AuthServiceServiceReadAction},
+		},
+		User: authServiceSchemaUserEntity{
+			Entity:         entity1,
+			ChangePassword: AuthServiceAction{entity1, AuthServiceUserChangePasswordAction},
+			Delete:         AuthServiceAction{entity1, AuthServiceUserDeleteAction},
+			SelfDelete:     AuthServiceAction{entity1, AuthServiceUserSelfDeleteAction},
+		},
+	}
+}()
@@ -0,0 +1,75 @@
+// Command rbacgen generates Go package with typed constants and ready-built values from RBAC configuration.
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/abaxoth0/SentinelRBAC/cmd/rbacgen -config RBAC.json -package rbacconst -out rbac_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+	"github.com/abaxoth0/SentinelRBAC/codegen"
+)
+
+func main() {
+	configPath := flag.String("config", "RBAC.json", "path to the RBAC configuration file")
+	isSchema := flag.Bool("schema", false, "load configuration as a single schema instead of a host")
+	pkg := flag.String("package", "", "name of the generated package (default: name of the output directory)")
+	out := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if err := run(*configPath, *isSchema, *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "rbacgen: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, isSchema bool, pkg string, out string) error {
+	if pkg == "" {
+		dir := "."
+		if out != "" {
+			dir = filepath.Dir(out)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		pkg = filepath.Base(abs)
+	}
+
+	opts := codegen.Options{
+		Package: pkg,
+		Source:  filepath.ToSlash(configPath),
+	}
+
+	var source []byte
+
+	if isSchema {
+		schema, err := rbac.LoadSchema(configPath)
+		if err != nil {
+			return err
+		}
+		if source, err = codegen.GenerateSchema(&schema, opts); err != nil {
+			return err
+		}
+	} else {
+		host, err := rbac.LoadHost(configPath)
+		if err != nil {
+			return err
+		}
+		if source, err = codegen.GenerateHost(&host, opts); err != nil {
+			return err
+		}
+	}
+
+	if out == "" {
+		_, err := os.Stdout.Write(source)
+		return err
+	}
+
+	return os.WriteFile(out, source, 0o644)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/abaxoth0/SentinelRBAC/report"
+)
+
+func generateReport(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := flags.String("format", "md", "report format: md, csv or html")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 1 {
+		return errUsage
+	}
+
+	host, err := loadHost()
+	if err != nil {
+		return err
+	}
+
+	var matrices []report.Matrix
+
+	if flags.NArg() == 1 {
+		schema, err := host.GetSchema(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		matrices = []report.Matrix{report.FromSchema(schema)}
+	} else {
+		matrices = report.FromHost(&host)
+	}
+
+	return report.Write(os.Stdout, report.Format(*format), matrices)
+}
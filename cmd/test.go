@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/abaxoth0/SentinelRBAC/rbactest"
+)
+
+func runTests(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	host, err := loadHost()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, path := range args {
+		suite, err := rbactest.Load(path)
+		if err != nil {
+			return errors.New(path + ": " + err.Error())
+		}
+
+		result := rbactest.Execute(&host, suite)
+
+		fmt.Println("=== " + path)
+
+		for _, c := range result.Cases {
+			if c.Passed {
+				fmt.Println("PASS  " + c.Case.Name)
+				continue
+			}
+			failed++
+			fmt.Println("FAIL  " + c.Case.Name)
+			fmt.Println("      " + c.Message)
+		}
+
+		coverage := result.Coverage
+
+		fmt.Printf(
+			"Coverage: %d/%d AGP rules, %d/%d actions\n",
+			coverage.TotalRules-len(coverage.UncoveredRules), coverage.TotalRules,
+			coverage.TotalActions-len(coverage.UncoveredActions), coverage.TotalActions,
+		)
+		for _, rule := range coverage.UncoveredRules {
+			fmt.Println("  not covered rule:   " + rule)
+		}
+		for _, act := range coverage.UncoveredActions {
+			fmt.Println("  not covered action: " + act)
+		}
+	}
+
+	if failed > 0 {
+		return errors.New(strconv.Itoa(failed) + " test case(-s) failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,387 @@
+// Package codegen generates Go code with typed constants and ready-built values
+// (entities, actions, resources and roles) from RBAC schemas.
+//
+// Generated code allows to build authorization contexts which are checked at compile time:
+//
+//	ctx := rbacconst.AuthService.User.Delete.On(rbacconst.AuthService.Resources.Cache)
+//
+// instead of using string literals which must match configuration exactly.
+package codegen
+
+import (
+	"errors"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+type Options struct {
+	// Name of the generated package.
+	Package string
+	// Optional. Will be mentioned in the header of generated file.
+	Source string
+}
+
+// Names of the permissions constants in the order of theirs bits.
+var permissionConstants = []string{
+	"CreatePermission",
+	"SelfCreatePermission",
+	"ReadPermission",
+	"SelfReadPermission",
+	"UpdatePermission",
+	"SelfUpdatePermission",
+	"DeletePermission",
+	"SelfDeletePermission",
+}
+
+func permissionsExpr(permissions rbac.Permissions) string {
+	var parts []string
+	for i, name := range permissionConstants {
+		if permissions&(1<<i) != 0 {
+			parts = append(parts, "rbac."+name)
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, " | ")
+}
+
+func matchExpr(match rbac.MatchStrategy) string {
+	switch match {
+	case rbac.AllMatchStrategy:
+		return "rbac.AllMatchStrategy"
+	case rbac.AnyMatchStrategy:
+		return "rbac.AnyMatchStrategy"
+	default:
+		return "rbac.MatchStrategy(" + quote(string(match)) + ")"
+	}
+}
+
+// Converts name from configuration into exported Go identifier, e.g. "change-password" -> "ChangePassword".
+func identifier(name string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	ident := b.String()
+	if ident == "" || unicode.IsDigit(rune(ident[0])) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+// namespace ensures that generated identifiers are unique in some scope.
+type namespace struct {
+	scope string
+	names map[string]string
+}
+
+func newNamespace(scope string, reserved ...string) *namespace {
+	ns := &namespace{scope: scope, names: map[string]string{}}
+	for _, name := range reserved {
+		ns.names[name] = ""
+	}
+	return ns
+}
+
+// Returns identifier for the name, if it's reserved then suffix is appended to it.
+func (ns *namespace) add(name string, suffix string) (string, error) {
+	ident := identifier(name)
+
+	if original, ok := ns.names[ident]; ok && original == "" {
+		ident += suffix
+	}
+
+	if original, ok := ns.names[ident]; ok {
+		return "", errors.New(
+			"names \"" + original + "\" and \"" + name + "\" produce the same identifier " + ident + " in " + ns.scope,
+		)
+	}
+
+	ns.names[ident] = name
+
+	return ident, nil
+}
+
+func quote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s) + "\""
+}
+
+func unexported(ident string) string {
+	return strings.ToLower(ident[:1]) + ident[1:]
+}
+
+func writeHeader(b *strings.Builder, opts Options) {
+	b.WriteString("// Code generated by rbacgen. DO NOT EDIT.\n")
+	if opts.Source != "" {
+		b.WriteString("// Source: " + opts.Source + "\n")
+	}
+	b.WriteString("\npackage " + opts.Package + "\n\n")
+	b.WriteString("import rbac \"github.com/abaxoth0/SentinelRBAC\"\n\n")
+	b.WriteString(`func newEntity(name string, actions map[rbac.Action]rbac.Permissions) *rbac.Entity {
+	entity := rbac.NewEntity(name)
+	for act, permissions := range actions {
+		if _, err := entity.NewAction(act.String(), permissions); err != nil {
+			panic(err)
+		}
+	}
+	return &entity
+}
+`)
+}
+
+type generatedEntity struct {
+	ident   string
+	entity  rbac.Entity
+	actions []string
+	consts  []string
+}
+
+func writeSchema(b *strings.Builder, schema *rbac.Schema, schemas *namespace) error {
+	prefix, err := schemas.add(schema.ID, "Schema")
+	if err != nil {
+		return err
+	}
+
+	typeName := unexported(prefix) + "Schema"
+	resourceType := prefix + "Resource"
+	actionType := prefix + "Action"
+
+	b.WriteString("\n// Schema " + quote(schema.ID) + "\n\n")
+	b.WriteString("const " + prefix + "SchemaID = " + quote(schema.ID) + "\n")
+
+	// Roles
+	roles := newNamespace("roles of the " + schema.ID + " schema")
+	roleIdents := make([]string, len(schema.Roles))
+	if len(schema.Roles) > 0 {
+		b.WriteString("\n// Names of the " + quote(schema.ID) + " schema roles.\nconst (\n")
+		for i, role := range schema.Roles {
+			ident, err := roles.add(role.Name, "Role")
+			if err != nil {
+				return err
+			}
+			roleIdents[i] = ident
+			b.WriteString("\t" + prefix + ident + "RoleName = " + quote(role.Name) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+
+	// Resources
+	resources := newNamespace("resources of the " + schema.ID + " schema")
+	resourceIdents := make([]string, len(schema.Resources))
+	if len(schema.Resources) > 0 {
+		b.WriteString("\n// Names of the " + quote(schema.ID) + " schema resources.\nconst (\n")
+		for i := range schema.Resources {
+			name := schema.Resources[i].Name()
+			ident, err := resources.add(name, "Resource")
+			if err != nil {
+				return err
+			}
+			resourceIdents[i] = ident
+			b.WriteString("\t" + prefix + ident + "ResourceName = " + quote(name) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+
+	// Entities and actions
+	entityNames := newNamespace("entities of the "+schema.ID+" schema", "ID", "Roles", "DefaultRoles", "Resources")
+	entities := make([]generatedEntity, len(schema.Entities))
+	for i, entity := range schema.Entities {
+		ident, err := entityNames.add(entity.Name(), "Entity")
+		if err != nil {
+			return err
+		}
+
+		generated := generatedEntity{ident: ident, entity: entity}
+		actions := newNamespace("actions of the "+entity.Name()+" entity", "Entity")
+		consts := newNamespace("actions of the " + entity.Name() + " entity")
+
+		for _, act := range entity.Actions() {
+			actionIdent, err := actions.add(act.String(), "Action")
+			if err != nil {
+				return err
+			}
+			constIdent, err := consts.add(act.String(), "")
+			if err != nil {
+				return err
+			}
+			generated.actions = append(generated.actions, actionIdent)
+			generated.consts = append(generated.consts, prefix+ident+constIdent+"Action")
+		}
+
+		entities[i] = generated
+	}
+
+	for _, entity := range entities {
+		if len(entity.actions) == 0 {
+			continue
+		}
+		b.WriteString("\n// Actions of the " + quote(entity.entity.Name()) + " entity.\nconst (\n")
+		for i, act := range entity.entity.Actions() {
+			b.WriteString("\t" + entity.consts[i] + " rbac.Action = " + quote(act.String()) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+
+	b.WriteString("\n// " + resourceType + " is a resource of the " + quote(schema.ID) + " schema.\n")
+	b.WriteString("type " + resourceType + " struct {\n\t*rbac.Resource\n}\n")
+
+	b.WriteString("\n// " + actionType + " is an action of the " + quote(schema.ID) + " schema entity.\n")
+	b.WriteString("type " + actionType + " struct {\n\tEntity *rbac.Entity\n\tAction rbac.Action\n}\n")
+	b.WriteString("\n// On returns authorization context of this action on the given resource.\n")
+	b.WriteString("func (a " + actionType + ") On(resource " + resourceType + ") rbac.AuthorizationContext {\n")
+	b.WriteString("\treturn rbac.NewAuthorizationContext(a.Entity, a.Action, resource.Resource)\n}\n")
+
+	b.WriteString("\ntype " + typeName + "Roles struct {\n")
+	for _, ident := range roleIdents {
+		b.WriteString("\t" + ident + " rbac.Role\n")
+	}
+	b.WriteString("}\n")
+
+	b.WriteString("\ntype " + typeName + "Resources struct {\n")
+	for _, ident := range resourceIdents {
+		b.WriteString("\t" + ident + " " + resourceType + "\n")
+	}
+	b.WriteString("}\n")
+
+	for _, entity := range entities {
+		b.WriteString("\ntype " + typeName + entity.ident + "Entity struct {\n\tEntity *rbac.Entity\n")
+		for _, act := range entity.actions {
+			b.WriteString("\t" + act + " " + actionType + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString("\ntype " + typeName + " struct {\n")
+	b.WriteString("\tID string\n")
+	b.WriteString("\tRoles " + typeName + "Roles\n")
+	b.WriteString("\tDefaultRoles []rbac.Role\n")
+	b.WriteString("\tResources " + typeName + "Resources\n")
+	for _, entity := range entities {
+		b.WriteString("\t" + entity.ident + " " + typeName + entity.ident + "Entity\n")
+	}
+	b.WriteString("}\n")
+
+	// Values
+	b.WriteString("\n// " + prefix + " contains ready-built values of the " + quote(schema.ID) + " schema.\n")
+	b.WriteString("var " + prefix + " = func() " + typeName + " {\n")
+
+	for i, entity := range entities {
+		b.WriteString("\tentity" + strconv.Itoa(i) + " := newEntity(" + quote(entity.entity.Name()) + ", map[rbac.Action]rbac.Permissions{\n")
+		for i, act := range entity.entity.Actions() {
+			permissions, _ := entity.entity.GetRequiredActionPermissions(act)
+			b.WriteString("\t\t" + entity.consts[i] + ": " + permissionsExpr(permissions) + ",\n")
+		}
+		b.WriteString("\t})\n")
+		if match := entity.entity.Match(); match != "" {
+			b.WriteString("\tentity" + strconv.Itoa(i) + ".SetMatch(" + matchExpr(match) + ")\n")
+		}
+		for j, act := range entity.entity.Actions() {
+			if match := entity.entity.ActionMatch(act); match != "" {
+				b.WriteString("\tentity" + strconv.Itoa(i) + ".SetActionMatch(" + entity.consts[j] + ", " + matchExpr(match) + ")\n")
+			}
+		}
+	}
+
+	b.WriteString("\n\treturn " + typeName + "{\n")
+	b.WriteString("\t\tID: " + prefix + "SchemaID,\n")
+
+	b.WriteString("\t\tRoles: " + typeName + "Roles{\n")
+	for i, role := range schema.Roles {
+		b.WriteString("\t\t\t" + roleIdents[i] + ": rbac.NewRole(" + prefix + roleIdents[i] + "RoleName, " + permissionsExpr(role.Permissions) + "),\n")
+	}
+	b.WriteString("\t\t},\n")
+
+	b.WriteString("\t\tDefaultRoles: []rbac.Role{\n")
+	for _, role := range schema.DefaultRoles {
+		name := quote(role.Name)
+		for i := range schema.Roles {
+			if schema.Roles[i].Name == role.Name {
+				name = prefix + roleIdents[i] + "RoleName"
+				break
+			}
+		}
+		b.WriteString("\t\t\trbac.NewRole(" + name + ", " + permissionsExpr(role.Permissions) + "),\n")
+	}
+	b.WriteString("\t\t},\n")
+
+	b.WriteString("\t\tResources: " + typeName + "Resources{\n")
+	for i, ident := range resourceIdents {
+		b.WriteString("\t\t\t" + ident + ": " + resourceType + "{rbac.NewResource(" + prefix + resourceIdents[i] + "ResourceName)},\n")
+	}
+	b.WriteString("\t\t},\n")
+
+	for i, entity := range entities {
+		entityVar := "entity" + strconv.Itoa(i)
+		b.WriteString("\t\t" + entity.ident + ": " + typeName + entity.ident + "Entity{\n")
+		b.WriteString("\t\t\tEntity: " + entityVar + ",\n")
+		for i, act := range entity.actions {
+			b.WriteString("\t\t\t" + act + ": " + actionType + "{" + entityVar + ", " + entity.consts[i] + "},\n")
+		}
+		b.WriteString("\t\t},\n")
+	}
+
+	b.WriteString("\t}\n}()\n")
+
+	return nil
+}
+
+func generate(schemas []*rbac.Schema, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, errors.New("package name is missing")
+	}
+
+	var b strings.Builder
+
+	writeHeader(&b, opts)
+
+	ns := newNamespace("schemas")
+	for _, schema := range schemas {
+		if err := writeSchema(&b, schema, ns); err != nil {
+			return nil, err
+		}
+	}
+
+	source, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, errors.New("failed to format generated code: " + err.Error())
+	}
+
+	return source, nil
+}
+
+// Generates Go source code for the schema.
+func GenerateSchema(schema *rbac.Schema, opts Options) ([]byte, error) {
+	return generate([]*rbac.Schema{schema}, opts)
+}
+
+// Generates Go source code for all schemas of the host, schemas are sorted by their IDs.
+func GenerateHost(host *rbac.Host, opts Options) ([]byte, error) {
+	schemas := make([]*rbac.Schema, len(host.Schemas))
+	for i := range host.Schemas {
+		schemas[i] = &host.Schemas[i]
+	}
+
+	sort.SliceStable(schemas, func(i, j int) bool {
+		return schemas[i].ID < schemas[j].ID
+	})
+
+	return generate(schemas, opts)
+}
@@ -0,0 +1,109 @@
+package codegen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"change-password":  "ChangePassword",
+		"unconfirmed_user": "UnconfirmedUser",
+		"auth service":     "AuthService",
+		"5b87cfb3-4d13":    "X5b87cfb34d13",
+		"---":              "X",
+		"user":             "User",
+	}
+
+	for name, expected := range tests {
+		if got := identifier(name); got != expected {
+			t.Errorf("identifier(%q): expected %s, got %s", name, expected, got)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	ns := newNamespace("test", "Id")
+
+	if ident, err := ns.add("id", "Entity"); err != nil || ident != "IdEntity" {
+		t.Errorf("Expected reserved name to get suffix, got %s, %v", ident, err)
+	}
+	if _, err := ns.add("self-delete", "Entity"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ns.add("self_delete", "Entity"); err == nil {
+		t.Error("Expected error for names which produce the same identifier")
+	}
+}
+
+// Generated example must be up to date with the example configuration.
+func TestGenerateHostGolden(t *testing.T) {
+	host, err := rbac.LoadHost("../cmd/RBAC.json")
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	source, err := GenerateHost(&host, Options{Package: "rbacconst", Source: "../RBAC.json"})
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	golden, err := os.ReadFile("../cmd/rbacconst/rbac_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(source, golden) {
+		t.Error("Generated code differs from cmd/rbacconst/rbac_gen.go, run go generate ./cmd/rbacconst")
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	user := rbac.NewEntity("user")
+	user.NewAction("entity", rbac.ReadPermission)
+	user.NewAction("ping", 0)
+	user.SetMatch(rbac.AnyMatchStrategy)
+	user.SetActionMatch("ping", rbac.AllMatchStrategy)
+
+	roles := []rbac.Role{rbac.NewRole("admin", rbac.ReadPermission|rbac.DeletePermission)}
+
+	schema := rbac.NewSchema("5b87cfb3", roles, roles, rbac.NewActionGatePolicy())
+	schema.Entities = []rbac.Entity{user, rbac.NewEntity("roles")}
+	schema.Resources = []rbac.Resource{*rbac.NewResource("cache")}
+
+	source, err := GenerateSchema(&schema, Options{Package: "gen"})
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", source, parser.AllErrors); err != nil {
+		t.Fatalf("Generated code is invalid: %v\n%s", err, source)
+	}
+
+	for _, s := range []string{
+		"package gen",
+		"const X5b87cfb3SchemaID = \"5b87cfb3\"",
+		"X5b87cfb3UserEntityAction rbac.Action = \"entity\"",
+		"EntityAction X5b87cfb3Action",
+		"X5b87cfb3UserPingAction   rbac.Action = \"ping\"",
+		"rbac.NewRole(X5b87cfb3AdminRoleName, rbac.ReadPermission|rbac.DeletePermission)",
+		"RolesEntity  x5b87cfb3SchemaRolesEntityEntity",
+		"Cache: X5b87cfb3Resource{rbac.NewResource(X5b87cfb3CacheResourceName)}",
+		"entity0.SetMatch(rbac.AnyMatchStrategy)",
+		"entity0.SetActionMatch(X5b87cfb3UserPingAction, rbac.AllMatchStrategy)",
+	} {
+		if !strings.Contains(string(source), s) {
+			t.Errorf("Expected generated code to contain %q:\n%s", s, source)
+		}
+	}
+
+	if _, err := GenerateSchema(&schema, Options{}); err == nil {
+		t.Error("Expected error for missing package name")
+	}
+}
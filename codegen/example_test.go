@@ -0,0 +1,22 @@
+package codegen_test
+
+import (
+	"fmt"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+	"github.com/abaxoth0/SentinelRBAC/cmd/rbacconst"
+)
+
+func Example() {
+	schema := rbacconst.AuthService
+
+	ctx := schema.User.Delete.On(schema.Resources.Cache)
+
+	fmt.Println(ctx.String())
+	fmt.Println(rbac.Authorize(&ctx, []rbac.Role{schema.Roles.Admin}, nil))
+	fmt.Println(rbac.Authorize(&ctx, []rbac.Role{schema.Roles.Support}, nil))
+	// Output:
+	// user:delete:cache
+	// <nil>
+	// insufficient permissions to perform this action (user:delete:cache, missing: delete)
+}
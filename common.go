@@ -1,8 +1,6 @@
 package rbac
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"io"
 	"os"
@@ -12,19 +10,35 @@ type loadable[T any] interface {
 	NormalizeAndValidate() (T, error)
 }
 
-// postLoad is called after file was loaded and parsed, but before normalization and validation.
-func load[T any, R loadable[T]](path string, postLoad func(*R)) (T, error) {
-	var zero T
+// Options of the host and schema loading.
+type LoadOptions struct {
+	// Format of the configuration files. If empty, then it's determined by the file extension.
+	Format ConfigFormat
+	// Environment, which overlay must be applied on top of the base configuration.
+	// Overlay is a file next to the base one, with the environment name before extension,
+	// e.g. for "RBAC.json" and "prod" environment overlay is "RBAC.prod.json".
+	// If empty, then overlay isn't applied.
+	Env string
+}
 
-	Debug.Log("Loading '" + path + "'...")
+func (o LoadOptions) format(path string) ConfigFormat {
+	if o.Format != "" {
+		return o.Format
+	}
+	return ConfigFormatFromPath(path)
+}
+
+// Reads configuration file, substitutes environment variables in it and decodes it.
+func readRaw[R any](path string, format ConfigFormat) (R, error) {
+	var raw R
 
 	file, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return zero, errors.New("RBAC host configuration file wasn't found")
+			return raw, errors.New("RBAC configuration file '" + path + "' wasn't found")
 		}
 
-		return zero, err
+		return raw, err
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
@@ -34,16 +48,36 @@ func load[T any, R loadable[T]](path string, postLoad func(*R)) (T, error) {
 
 	buf, err := io.ReadAll(file)
 	if err != nil {
-		return zero, err
+		return raw, err
 	}
 
-	var raw R
-	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(&raw); err != nil {
-		return zero, errors.New("Failed to parse RBAC host configuration file: " + err.Error())
+	buf, err = substituteEnv(buf, format)
+	if err != nil {
+		return raw, errors.New("Failed to substitute environment variables in '" + path + "': " + err.Error())
+	}
+
+	if err := decode(buf, format, &raw); err != nil {
+		return raw, errors.New("Failed to parse RBAC configuration file '" + path + "': " + err.Error())
+	}
+
+	return raw, nil
+}
+
+// compose is called after file was loaded and parsed, but before normalization and validation.
+func load[T any, R loadable[T]](path string, format ConfigFormat, compose func(*R) error) (T, error) {
+	var zero T
+
+	Debug.Log("Loading '" + path + "'...")
+
+	raw, err := readRaw[R](path, format)
+	if err != nil {
+		return zero, err
 	}
 
-	if postLoad != nil {
-		postLoad(&raw)
+	if compose != nil {
+		if err := compose(&raw); err != nil {
+			return zero, err
+		}
 	}
 
 	result, err := raw.NormalizeAndValidate()
@@ -55,3 +89,17 @@ func load[T any, R loadable[T]](path string, postLoad func(*R)) (T, error) {
 
 	return result, nil
 }
+
+func containsString(s []string, v string) bool {
+	return indexOfString(s, v) != -1
+}
+
+// Returns index of the first occurrence of v in s, -1 if s doesn't contain it.
+func indexOfString(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,427 @@
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Replaces each ${NAME} in the string with the value of the environment variable NAME.
+// Names of the variables which aren't set are appended to the missing.
+func substituteEnvString(s string, missing *[]string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := envVarPattern.FindStringSubmatch(match)[1]
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if !containsString(*missing, name) {
+				*missing = append(*missing, name)
+			}
+			return match
+		}
+
+		return value
+	})
+}
+
+// Substitutes environment variables in the configuration (see substituteEnvString).
+// Variables are substituted only inside string values of JSON and YAML, so their values can't change
+// structure of the configuration. YAML is returned as JSON (which is valid YAML as well).
+// DSL has no string literals, so in DSL value of the variable must be a single name (without spaces, commas, etc.).
+// Returns an error if any of the variables isn't set.
+func substituteEnv(data []byte, format ConfigFormat) ([]byte, error) {
+	if !envVarPattern.Match(data) {
+		return data, nil
+	}
+
+	var missing []string
+	var result []byte
+
+	switch format {
+	case DSLConfigFormat:
+		var invalid []string
+		result = []byte(envVarPattern.ReplaceAllStringFunc(string(data), func(match string) string {
+			value := substituteEnvString(match, &missing)
+			if value != match && (value == "" || strings.ContainsAny(value, " \t\r\n#,{}:")) {
+				invalid = append(invalid, envVarPattern.FindStringSubmatch(match)[1])
+			}
+			return value
+		}))
+		if len(invalid) > 0 {
+			return nil, errors.New("environment variable(-s) can't be used in DSL, since their values aren't single names: " + strings.Join(invalid, ", "))
+		}
+	case YAMLConfigFormat, JSONConfigFormat:
+		if format == YAMLConfigFormat {
+			converted, err := yamlToJSON(data)
+			if err != nil {
+				return nil, err
+			}
+			data = converted
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		var err error
+		result, err = json.Marshal(substituteEnvValue(v, &missing))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, format.Validate()
+	}
+
+	if len(missing) > 0 {
+		return nil, errors.New("environment variable(-s) not set: " + strings.Join(missing, ", "))
+	}
+
+	return result, nil
+}
+
+// Substitutes environment variables in all string values of the decoded JSON value.
+func substituteEnvValue(v any, missing *[]string) any {
+	switch v := v.(type) {
+	case string:
+		return substituteEnvString(v, missing)
+	case []any:
+		for i := range v {
+			v[i] = substituteEnvValue(v[i], missing)
+		}
+	case map[string]any:
+		for key, value := range v {
+			v[key] = substituteEnvValue(value, missing)
+		}
+	}
+	return v
+}
+
+// Returns path of the environment overlay for the configuration file at the specified path.
+// For example, overlay of the "RBAC.json" for the "prod" environment is "RBAC.prod.json".
+func overlayPath(path string, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// Returns paths of all files which match include patterns.
+// Relative patterns are resolved relative to the directory of the including file.
+func resolveIncludes(dir string, patterns []string) ([]string, error) {
+	var paths []string
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid include pattern %s: %s", pattern, err.Error())
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("Include %s doesn't match any file", pattern)
+		}
+
+		for _, match := range matches {
+			if !containsString(paths, match) {
+				paths = append(paths, match)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// Loads all schemas included by the host at the specified path and appends them to the host schemas.
+func (h *rawHost) includeSchemas(path string) error {
+	paths, err := resolveIncludes(filepath.Dir(path), h.Include)
+	if err != nil {
+		return err
+	}
+
+	for _, schemaPath := range paths {
+		schema, err := readRaw[*rawSchema](schemaPath, ConfigFormatFromPath(schemaPath))
+		if err != nil {
+			return err
+		}
+
+		for _, existing := range h.Schemas {
+			if existing.ID == schema.ID {
+				return fmt.Errorf("Schema %s from %s is already defined", schema.ID, schemaPath)
+			}
+		}
+
+		h.Schemas = append(h.Schemas, schema)
+	}
+
+	h.Include = nil
+
+	return nil
+}
+
+// Reads host at the specified path with all its includes and applies environment overlay on top of it.
+func (h *rawHost) compose(path string, opts LoadOptions) error {
+	if err := h.includeSchemas(path); err != nil {
+		return err
+	}
+
+	if opts.Env == "" {
+		return nil
+	}
+
+	overlayFile := overlayPath(path, opts.Env)
+
+	overlay, err := readRaw[rawHost](overlayFile, opts.format(overlayFile))
+	if err != nil {
+		return err
+	}
+	if err := overlay.includeSchemas(overlayFile); err != nil {
+		return err
+	}
+
+	h.merge(&overlay)
+
+	return nil
+}
+
+// Reads schema at the specified path and applies environment overlay on top of it.
+func (s *rawSchema) compose(path string, opts LoadOptions) error {
+	if opts.Env == "" {
+		return nil
+	}
+
+	overlayFile := overlayPath(path, opts.Env)
+
+	overlay, err := readRaw[*rawSchema](overlayFile, opts.format(overlayFile))
+	if err != nil {
+		return err
+	}
+
+	s.merge(overlay)
+
+	return nil
+}
+
+// Roles of the overlay replace roles with the same names, other roles are appended.
+func mergeRawRoles(base []*rawRole, overlay []*rawRole) []*rawRole {
+	for _, role := range overlay {
+		replaced := false
+		for i, baseRole := range base {
+			if baseRole.Name == role.Name {
+				base[i] = role
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, role)
+		}
+	}
+	return base
+}
+
+// Entities are merged by their names, actions of the overlay entity replace actions with the same names,
+// match strategy of the overlay entity replaces the base one if it's set.
+func mergeRawEntities(base []*rawEntity, overlay []*rawEntity) []*rawEntity {
+	for _, entity := range overlay {
+		var baseEntity *rawEntity
+		for _, e := range base {
+			if e.Name == entity.Name {
+				baseEntity = e
+				break
+			}
+		}
+		if baseEntity == nil {
+			base = append(base, entity)
+			continue
+		}
+		if entity.Match != "" {
+			baseEntity.Match = entity.Match
+		}
+
+		for _, act := range entity.Actions {
+			replaced := false
+			for i, baseAct := range baseEntity.Actions {
+				if baseAct.Name == act.Name {
+					baseEntity.Actions[i] = act
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				baseEntity.Actions = append(baseEntity.Actions, act)
+			}
+		}
+	}
+	return base
+}
+
+func mergeStrings(base []string, overlay []string) []string {
+	for _, s := range overlay {
+		if !containsString(base, s) {
+			base = append(base, s)
+		}
+	}
+	return base
+}
+
+// Splits rules, so each of them has exactly one entity and one action.
+func expandRawRules(rules []*rawActionGateRules) []*rawActionGateRules {
+	var result []*rawActionGateRules
+
+	for _, rule := range rules {
+		for _, entity := range rule.For {
+			for _, act := range rule.Doing {
+				result = append(result, &rawActionGateRules{
+					For:    []string{entity},
+					Having: rule.Having,
+					Apply:  rule.Apply,
+					Doing:  []string{act},
+					On:     rule.On,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// Overlay rule replaces base rule for the same entity:action:resource context, other rules are appended.
+func mergeRawRules(base []*rawActionGateRules, overlay []*rawActionGateRules) []*rawActionGateRules {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	result := expandRawRules(base)
+
+	for _, rule := range expandRawRules(overlay) {
+		replaced := false
+		for i, r := range result {
+			if r.For[0] == rule.For[0] && r.Doing[0] == rule.Doing[0] && r.On == rule.On {
+				result[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
+
+func mergeRawTenants(base []*rawTenant, overlay []*rawTenant) []*rawTenant {
+	for _, tenant := range overlay {
+		merged := false
+		for _, t := range base {
+			if t.ID == tenant.ID {
+				t.ActionGatePolicy = mergeRawRules(t.ActionGatePolicy, tenant.ActionGatePolicy)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			base = append(base, tenant)
+		}
+	}
+	return base
+}
+
+func mergeRawRelations(base []*rawRelationType, overlay []*rawRelationType) []*rawRelationType {
+	for _, relationType := range overlay {
+		var baseType *rawRelationType
+		for _, t := range base {
+			if t.Type == relationType.Type {
+				baseType = t
+				break
+			}
+		}
+		if baseType == nil {
+			base = append(base, relationType)
+			continue
+		}
+
+		for _, relation := range relationType.Relations {
+			replaced := false
+			for i, r := range baseType.Relations {
+				if r.Name == relation.Name {
+					baseType.Relations[i] = relation
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				baseType.Relations = append(baseType.Relations, relation)
+			}
+		}
+	}
+	return base
+}
+
+// Applies overlay on top of this schema:
+//   - default roles and role merge strategy are replaced if overlay specifies them;
+//   - roles (including their merge strategies), entities, actions, tenants and relations are merged by their names, overlay takes precedence;
+//   - AGP rules are merged by their contexts, overlay takes precedence;
+//   - resources, relation grants and suppressions are appended.
+func (s *rawSchema) merge(overlay *rawSchema) {
+	if len(overlay.DefaultRolesNames) > 0 {
+		s.DefaultRolesNames = overlay.DefaultRolesNames
+	}
+	if overlay.RoleMerge != "" {
+		s.RoleMerge = overlay.RoleMerge
+	}
+
+	s.Roles = mergeRawRoles(s.Roles, overlay.Roles)
+	s.Entities = mergeRawEntities(s.Entities, overlay.Entities)
+	s.Resources = mergeStrings(s.Resources, overlay.Resources)
+	s.ActionGatePolicy = mergeRawRules(s.ActionGatePolicy, overlay.ActionGatePolicy)
+	s.Relations = mergeRawRelations(s.Relations, overlay.Relations)
+	s.RelationGrants = append(s.RelationGrants, overlay.RelationGrants...)
+	s.Tenants = mergeRawTenants(s.Tenants, overlay.Tenants)
+	s.Suppress = append(s.Suppress, overlay.Suppress...)
+}
+
+// Applies overlay on top of this host. Global roles, default roles and role merge strategy are merged in the same way
+// as for schemas, schemas are merged by their IDs (see rawSchema.merge), new schemas are appended.
+// Host-wide AGP rules are merged by their contexts, cross-schema grants of the overlay are appended.
+func (h *rawHost) merge(overlay *rawHost) {
+	if len(overlay.DefaultRolesNames) > 0 {
+		h.DefaultRolesNames = overlay.DefaultRolesNames
+	}
+	if overlay.RoleMerge != "" {
+		h.RoleMerge = overlay.RoleMerge
+	}
+
+	h.GlobalRoles = mergeRawRoles(h.GlobalRoles, overlay.GlobalRoles)
+	h.ActionGatePolicy = mergeRawRules(h.ActionGatePolicy, overlay.ActionGatePolicy)
+	if overlay.MissingReferences != "" {
+		h.MissingReferences = overlay.MissingReferences
+	}
+	h.Suppress = append(h.Suppress, overlay.Suppress...)
+	h.CrossSchemaGrants = append(h.CrossSchemaGrants, overlay.CrossSchemaGrants...)
+
+	for _, schema := range overlay.Schemas {
+		merged := false
+		for _, s := range h.Schemas {
+			if s.ID == schema.ID {
+				s.merge(schema)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			h.Schemas = append(h.Schemas, schema)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSubstituteEnv(t *testing.T) {
+	t.Setenv("RBAC_TEST_ROLE", "admin")
+
+	result, err := substituteEnv([]byte(`{"having": ["${RBAC_TEST_ROLE}"], "price": "$5", "other": "${RBAC_TEST_ROLE}"}`), JSONConfigFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != `{"having":["admin"],"other":"admin","price":"$5"}` {
+		t.Errorf("Unexpected result %s", result)
+	}
+
+	result, err = substituteEnv([]byte("role ${RBAC_TEST_ROLE}: read # $5"), DSLConfigFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "role admin: read # $5" {
+		t.Errorf("Unexpected result %s", result)
+	}
+
+	_, err = substituteEnv([]byte(`["${RBAC_TEST_MISSING}", "${RBAC_TEST_MISSING}"]`), JSONConfigFormat)
+	if err == nil || !strings.HasSuffix(err.Error(), ": RBAC_TEST_MISSING") {
+		t.Errorf("Expected error for missing variable, got %v", err)
+	}
+}
+
+func TestSubstituteEnvInjection(t *testing.T) {
+	t.Setenv("RBAC_TEST_ROLE", `x","permissions":{"delete":true,"read":true}},{"name":"y`)
+
+	files := map[string]string{
+		"RBAC.json": `{"roles": [{"name": "${RBAC_TEST_ROLE}", "permissions": {"self-read": true}}], "schemas": [{"id": "svc"}]}`,
+		"RBAC.yaml": "roles:\n  - name: \"${RBAC_TEST_ROLE}\"\n    permissions: self-read\nschemas:\n  - id: svc\n",
+	}
+	dir := writeTestFiles(t, files)
+
+	for name := range files {
+		t.Run(name, func(t *testing.T) {
+			host, err := LoadHost(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("Failed to load host: %v", err)
+			}
+			if len(host.GlobalRoles) != 1 || host.GlobalRoles[0].Name != os.Getenv("RBAC_TEST_ROLE") || host.GlobalRoles[0].Permissions != SelfReadPermission {
+				t.Errorf("Expected value to be substituted as is, got %v", host.GlobalRoles)
+			}
+		})
+	}
+
+	t.Setenv("RBAC_TEST_ROLE", "x: delete\nrole y")
+	dir = writeTestFiles(t, map[string]string{"RBAC.rbac": "role ${RBAC_TEST_ROLE}: self-read\n"})
+	if _, err := LoadHost(filepath.Join(dir, "RBAC.rbac")); err == nil {
+		t.Error("Expected error for DSL variable which isn't a single name")
+	}
+}
+
+func TestMergeRoleMergeOverlay(t *testing.T) {
+	host := &rawHost{RoleMerge: "override", Schemas: []*rawSchema{{ID: "auth"}, {ID: "billing", RoleMerge: "forbid"}}}
+	host.merge(&rawHost{RoleMerge: "union", Schemas: []*rawSchema{{ID: "auth", RoleMerge: "intersection"}, {ID: "billing"}}})
+
+	if host.RoleMerge != "union" || host.Schemas[0].RoleMerge != "intersection" {
+		t.Errorf("Expected role-merge of the overlay to be applied, got %q and %q", host.RoleMerge, host.Schemas[0].RoleMerge)
+	}
+	if host.Schemas[1].RoleMerge != "forbid" {
+		t.Errorf("Expected role-merge to be kept if overlay doesn't specify it, got %q", host.Schemas[1].RoleMerge)
+	}
+}
+
+func TestOverlayPath(t *testing.T) {
+	tests := map[string]string{
+		"RBAC.json":          "RBAC.prod.json",
+		"conf/RBAC.yaml":     "conf/RBAC.prod.yaml",
+		"RBAC":               "RBAC.prod",
+		"conf.d/schema.rbac": "conf.d/schema.prod.rbac",
+	}
+
+	for path, expected := range tests {
+		if result := overlayPath(path, "prod"); result != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, result)
+		}
+	}
+}
+
+const composeHost = `{
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true}},
+		{"name": "admin", "permissions": {"read": true, "delete": true}}
+	],
+	"default-roles": ["user"],
+	"include": ["schemas/*.json", "billing.yaml"],
+	"schemas": [{"id": "inline", "resources": ["cache"]}]
+}`
+
+func TestLoadHostIncludes(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"RBAC.json":          composeHost,
+		"schemas/auth.json":  `{"id": "auth", "resources": ["cache"], "entities": [{"name": "user", "actions": [{"name": "delete", "required-permissions": {"delete": true}}]}]}`,
+		"schemas/users.json": `{"id": "users", "resources": ["user"]}`,
+		"billing.yaml":       "id: billing\nresources: [invoice]\n",
+	})
+
+	host, err := LoadHost(filepath.Join(dir, "RBAC.json"))
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	var ids []string
+	for _, schema := range host.Schemas {
+		ids = append(ids, schema.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"inline", "auth", "users", "billing"}) {
+		t.Errorf("Unexpected schemas %v", ids)
+	}
+
+	// Global roles must be merged into included schemas as well
+	if _, err := host.Schemas[1].ParseRole("admin"); err != nil {
+		t.Error(err)
+	}
+
+	invalid := map[string]map[string]string{
+		"missing include": {
+			"RBAC.json": `{"include": ["missing.json"], "schemas": []}`,
+		},
+		"duplicate schema": {
+			"RBAC.json": `{"include": ["a.json"], "schemas": [{"id": "a"}]}`,
+			"a.json":    `{"id": "a"}`,
+		},
+	}
+
+	for name, files := range invalid {
+		t.Run(name, func(t *testing.T) {
+			dir := writeTestFiles(t, files)
+			if _, err := LoadHost(filepath.Join(dir, "RBAC.json")); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestLoadHostEnvOverlay(t *testing.T) {
+	t.Setenv("RBAC_TEST_SUPPORT_ROLE", "support")
+
+	dir := writeTestFiles(t, map[string]string{
+		"RBAC.json": `{
+			"roles": [
+				{"name": "user", "permissions": {"self-read": true}},
+				{"name": "admin", "permissions": {"read": true, "delete": true}}
+			],
+			"default-roles": ["user"],
+			"schemas": [{
+				"id": "auth",
+				"resources": ["cache"],
+				"entities": [{"name": "user", "actions": [
+					{"name": "read", "required-permissions": {"read": true}},
+					{"name": "delete", "required-permissions": {"delete": true}}
+				]}],
+				"action-gate-policy": [
+					{"for": ["user"], "doing": ["read", "delete"], "on": "cache", "apply": "require", "having": ["admin"]}
+				]
+			}]
+		}`,
+		"RBAC.prod.json": `{
+			"roles": [
+				{"name": "admin", "permissions": {"read": true}},
+				{"name": "${RBAC_TEST_SUPPORT_ROLE}", "permissions": {"read": true}}
+			],
+			"schemas": [
+				{
+					"id": "auth",
+					"resources": ["database"],
+					"entities": [{"name": "user", "actions": [
+						{"name": "delete", "required-permissions": {"delete": true, "update": true}},
+						{"name": "purge", "required-permissions": {"delete": true}}
+					]}],
+					"action-gate-policy": [
+						{"for": ["user"], "doing": ["read"], "on": "cache", "apply": "deny", "having": ["support"]}
+					]
+				},
+				{"id": "billing", "resources": ["invoice"]}
+			]
+		}`,
+	})
+
+	path := filepath.Join(dir, "RBAC.json")
+
+	base, err := LoadHost(path)
+	if err != nil {
+		t.Fatalf("Failed to load base host: %v", err)
+	}
+	if len(base.Schemas) != 1 || len(base.GlobalRoles) != 2 {
+		t.Errorf("Overlay must not be applied without env: %+v", base)
+	}
+
+	host, err := LoadHostOptions(path, LoadOptions{Env: "prod"})
+	if err != nil {
+		t.Fatalf("Failed to load host with overlay: %v", err)
+	}
+
+	if len(host.Schemas) != 2 {
+		t.Fatalf("Expected overlay schema to be appended, got %d schemas", len(host.Schemas))
+	}
+	if len(host.GlobalRoles) != 3 || host.GlobalRoles[1].Permissions != ReadPermission || host.GlobalRoles[2].Name != "support" {
+		t.Errorf("Unexpected global roles %v", host.GlobalRoles)
+	}
+	if len(host.DefaultRoles) != 1 || host.DefaultRoles[0].Name != "user" {
+		t.Errorf("Default roles must be kept if overlay doesn't specify them, got %v", host.DefaultRoles)
+	}
+
+	schema, _ := host.GetSchema("auth")
+
+	if len(schema.Resources) != 2 {
+		t.Errorf("Expected resources to be merged, got %v", schema.Resources)
+	}
+
+	user, _ := schema.getEntity("user")
+	if required, _ := user.GetRequiredActionPermissions("delete"); required != DeletePermission|UpdatePermission {
+		t.Errorf("Expected overlay action to replace base one, got %08b", required)
+	}
+	if !user.HasAction("read") || !user.HasAction("purge") {
+		t.Errorf("Expected actions to be merged, got %v", user.Actions())
+	}
+
+	cache, _ := schema.getResource("cache")
+	for act, expected := range map[Action]ActionGateEffect{"read": DenyActionGateEffect, "delete": RequireActionGateEffect} {
+		ctx := NewAuthorizationContext(user, act, cache)
+		rule, ok := schema.ActionGatePolicy.GetRule(&ctx)
+		if !ok || rule.Effect != expected {
+			t.Errorf("Expected %s rule for %s, got %v", expected, act, rule)
+		}
+	}
+
+	if _, err := LoadHostOptions(path, LoadOptions{Env: "staging"}); err == nil {
+		t.Error("Expected error for missing overlay")
+	}
+}
+
+func TestLoadSchemaEnvOverlay(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"schema.yaml":     "id: svc\ndefault-roles: [user]\nroles:\n  - name: user\n    permissions: {self-read: true}\nresources: [cache]\n",
+		"schema.dev.yaml": "default-roles: [debug]\nroles:\n  - name: debug\n    permissions: {read: true}\n",
+	})
+
+	schema, err := LoadSchemaOptions(filepath.Join(dir, "schema.yaml"), LoadOptions{Env: "dev"})
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	if len(schema.Roles) != 2 || len(schema.DefaultRoles) != 1 || schema.DefaultRoles[0].Name != "debug" {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+}
@@ -0,0 +1,355 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConditionOp is an operator of the condition.
+type ConditionOp string
+
+const (
+	// Condition is always satisfied.
+	TrueOp ConditionOp = "true"
+	// Condition is never satisfied.
+	FalseOp ConditionOp = "false"
+	// Attribute equals to the single value.
+	EqOp ConditionOp = "eq"
+	// Attribute equals to one of the values.
+	InOp ConditionOp = "in"
+	// All operands are satisfied.
+	AndOp ConditionOp = "and"
+	// At least one of operands is satisfied.
+	OrOp ConditionOp = "or"
+)
+
+// Condition over the resource attributes, e.g. result of the partial evaluation (see PartialEvaluate).
+//
+// Use constructors (True, False, Eq, In, And, Or) to create conditions, they simplify result where possible.
+type Condition struct {
+	Op ConditionOp
+	// Used by EqOp and InOp.
+	Attribute string
+	// Used by EqOp (single value) and InOp.
+	Values []any
+	// Used by AndOp and OrOp.
+	Operands []Condition
+}
+
+func True() Condition {
+	return Condition{Op: TrueOp}
+}
+
+func False() Condition {
+	return Condition{Op: FalseOp}
+}
+
+func Eq(attribute string, value any) Condition {
+	return Condition{Op: EqOp, Attribute: attribute, Values: []any{value}}
+}
+
+// Returns False condition if there are no values.
+func In(attribute string, values ...any) Condition {
+	switch len(values) {
+	case 0:
+		return False()
+	case 1:
+		return Eq(attribute, values[0])
+	}
+	return Condition{Op: InOp, Attribute: attribute, Values: values}
+}
+
+// Returns conjunction of the conditions. True and duplicate operands are dropped, nested conjunctions are flattened,
+// if any operand is False then result is False.
+func And(conditions ...Condition) Condition {
+	return combine(AndOp, TrueOp, FalseOp, conditions)
+}
+
+// Returns disjunction of the conditions. False and duplicate operands are dropped, nested disjunctions are flattened,
+// if any operand is True then result is True.
+func Or(conditions ...Condition) Condition {
+	return combine(OrOp, FalseOp, TrueOp, conditions)
+}
+
+// neutral operands are dropped, absorbing operand makes the whole result.
+func combine(op ConditionOp, neutral ConditionOp, absorbing ConditionOp, conditions []Condition) Condition {
+	var operands []Condition
+
+	for _, c := range conditions {
+		switch c.Op {
+		case neutral:
+			continue
+		case absorbing:
+			return c
+		case op:
+			operands = appendOperands(operands, c.Operands...)
+		default:
+			operands = appendOperands(operands, c)
+		}
+	}
+
+	switch len(operands) {
+	case 0:
+		return Condition{Op: neutral}
+	case 1:
+		return operands[0]
+	}
+
+	return Condition{Op: op, Operands: operands}
+}
+
+// Appends conditions which operands don't have yet.
+func appendOperands(operands []Condition, conditions ...Condition) []Condition {
+	for _, c := range conditions {
+		duplicate := false
+		for _, operand := range operands {
+			if reflect.DeepEqual(operand, c) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			operands = append(operands, c)
+		}
+	}
+	return operands
+}
+
+func (c Condition) IsTrue() bool {
+	return c.Op == TrueOp
+}
+
+func (c Condition) IsFalse() bool {
+	return c.Op == FalseOp
+}
+
+// Reports whether shape of the condition is valid: Eq has exactly one value, In has at least one value,
+// And and Or have at least one operand. Operands are validated as well.
+func (c Condition) Validate() error {
+	if err := c.validateOp(); err != nil {
+		return err
+	}
+	for _, operand := range c.Operands {
+		if err := operand.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Same as Validate, but operands aren't validated.
+func (c Condition) validateOp() error {
+	switch c.Op {
+	case TrueOp, FalseOp:
+	case EqOp:
+		if len(c.Values) != 1 {
+			return errors.New("eq condition on the \"" + c.Attribute + "\" attribute must have exactly one value")
+		}
+	case InOp:
+		if len(c.Values) == 0 {
+			return errors.New("in condition on the \"" + c.Attribute + "\" attribute must have at least one value")
+		}
+	case AndOp, OrOp:
+		if len(c.Operands) == 0 {
+			return errors.New(string(c.Op) + " condition must have at least one operand")
+		}
+	default:
+		return errors.New("unknown condition operator \"" + string(c.Op) + "\"")
+	}
+	return nil
+}
+
+// Returns human-readable form of the condition, e.g. `owner = "42" AND (status = "draft" OR status = "review")`.
+// Condition with invalid shape (see Validate) is returned as `<invalid condition op>`.
+func (c Condition) String() string {
+	if c.validateOp() != nil {
+		return "<invalid condition " + string(c.Op) + ">"
+	}
+
+	switch c.Op {
+	case TrueOp, FalseOp:
+		return string(c.Op)
+	case EqOp:
+		return c.Attribute + " = " + formatConditionValue(c.Values[0])
+	case InOp:
+		values := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = formatConditionValue(v)
+		}
+		return c.Attribute + " IN (" + strings.Join(values, ", ") + ")"
+	default: // AndOp, OrOp
+		operands := make([]string, len(c.Operands))
+		for i, operand := range c.Operands {
+			operands[i] = operand.String()
+			if operand.Op == AndOp || operand.Op == OrOp {
+				operands[i] = "(" + operands[i] + ")"
+			}
+		}
+		return strings.Join(operands, " "+strings.ToUpper(string(c.Op))+" ")
+	}
+}
+
+func formatConditionValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// Reports whether resource with the specified attributes satisfies the condition.
+// Values are compared with reflect.DeepEqual, so their types must match (e.g. int and int64 are different).
+// Missing attribute doesn't satisfy any Eq or In condition.
+func (c Condition) Eval(attributes map[string]any) bool {
+	switch c.Op {
+	case TrueOp:
+		return true
+	case EqOp, InOp:
+		value, ok := attributes[c.Attribute]
+		if !ok {
+			return false
+		}
+		for _, v := range c.Values {
+			if reflect.DeepEqual(value, v) {
+				return true
+			}
+		}
+		return false
+	case AndOp:
+		for _, operand := range c.Operands {
+			if !operand.Eval(attributes) {
+				return false
+			}
+		}
+		return true
+	case OrOp:
+		for _, operand := range c.Operands {
+			if operand.Eval(attributes) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// Returns Go predicate, which reports whether resource with the specified attributes satisfies the condition (see Condition.Eval).
+func (c Condition) Predicate() func(attributes map[string]any) bool {
+	return c.Eval
+}
+
+// SQLPlaceholder defines how parameters are referenced in the SQL.
+type SQLPlaceholder string
+
+const (
+	// "?" for each parameter, used by default (MySQL, SQLite).
+	QuestionPlaceholder SQLPlaceholder = "?"
+	// "$1", "$2", ... (PostgreSQL).
+	DollarPlaceholder SQLPlaceholder = "$"
+)
+
+type SQLOptions struct {
+	Placeholder SQLPlaceholder
+	// Number of the first parameter for DollarPlaceholder, 1 by default.
+	// Useful when condition is appended to the query which already has parameters.
+	FirstParam int
+	// Columns of the attributes. If attribute isn't in this map, then its name is used as column.
+	Columns map[string]string
+}
+
+var sqlIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type sqlWriter struct {
+	opts SQLOptions
+	b    strings.Builder
+	args []any
+}
+
+func (w *sqlWriter) param(v any) {
+	w.args = append(w.args, v)
+	if w.opts.Placeholder == DollarPlaceholder {
+		w.b.WriteString("$" + strconv.Itoa(w.opts.FirstParam+len(w.args)-1))
+	} else {
+		w.b.WriteString("?")
+	}
+}
+
+func (w *sqlWriter) column(attribute string) error {
+	column := attribute
+	if c, ok := w.opts.Columns[attribute]; ok {
+		column = c
+	}
+	if !sqlIdentifierPattern.MatchString(column) {
+		return errors.New("invalid SQL column \"" + column + "\" of the \"" + attribute + "\" attribute")
+	}
+	w.b.WriteString(column)
+	return nil
+}
+
+func (w *sqlWriter) write(c Condition) error {
+	if err := c.validateOp(); err != nil {
+		return err
+	}
+
+	switch c.Op {
+	case TrueOp:
+		w.b.WriteString("1 = 1")
+	case FalseOp:
+		w.b.WriteString("1 = 0")
+	case EqOp:
+		if err := w.column(c.Attribute); err != nil {
+			return err
+		}
+		w.b.WriteString(" = ")
+		w.param(c.Values[0])
+	case InOp:
+		if err := w.column(c.Attribute); err != nil {
+			return err
+		}
+		w.b.WriteString(" IN (")
+		for i, v := range c.Values {
+			if i > 0 {
+				w.b.WriteString(", ")
+			}
+			w.param(v)
+		}
+		w.b.WriteString(")")
+	case AndOp, OrOp:
+		for i, operand := range c.Operands {
+			if i > 0 {
+				w.b.WriteString(" " + strings.ToUpper(string(c.Op)) + " ")
+			}
+			compound := operand.Op == AndOp || operand.Op == OrOp
+			if compound {
+				w.b.WriteString("(")
+			}
+			if err := w.write(operand); err != nil {
+				return err
+			}
+			if compound {
+				w.b.WriteString(")")
+			}
+		}
+	}
+	return nil
+}
+
+// Renders condition as a parameterized SQL WHERE fragment (without "WHERE" keyword) and returns it with its parameters.
+// Values are never inlined into the SQL, columns are validated to be plain (optionally qualified) identifiers.
+// Returns error if condition has invalid shape (see Validate).
+func (c Condition) ToSQL(opts SQLOptions) (string, []any, error) {
+	if opts.FirstParam == 0 {
+		opts.FirstParam = 1
+	}
+
+	w := &sqlWriter{opts: opts}
+	if err := w.write(c); err != nil {
+		return "", nil, err
+	}
+
+	return w.b.String(), w.args, nil
+}
@@ -0,0 +1,132 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConditionConstructors(t *testing.T) {
+	owner := Eq("owner", "42")
+	status := In("status", "draft", "review")
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  Condition
+	}{
+		{"empty and", And(), True()},
+		{"empty or", Or(), False()},
+		{"and drops true", And(True(), owner), owner},
+		{"and with false", And(owner, False(), status), False()},
+		{"or drops false", Or(False(), owner), owner},
+		{"or with true", Or(owner, True()), True()},
+		{"and flattens", And(owner, And(status, Eq("x", 1))), Condition{Op: AndOp, Operands: []Condition{owner, status, Eq("x", 1)}}},
+		{"duplicates", Or(owner, status, owner), Condition{Op: OrOp, Operands: []Condition{owner, status}}},
+		{"in without values", In("status"), False()},
+		{"in with single value", In("status", "draft"), Eq("status", "draft")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.condition, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tt.condition)
+			}
+		})
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	condition := And(Eq("tenant", "acme"), Or(Eq("owner", "42"), In("status", "public", "shared")))
+
+	if expected := `tenant = "acme" AND (owner = "42" OR status IN ("public", "shared"))`; condition.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, condition.String())
+	}
+
+	tests := []struct {
+		name       string
+		attributes map[string]any
+		expected   bool
+	}{
+		{"owned", map[string]any{"tenant": "acme", "owner": "42", "status": "draft"}, true},
+		{"shared", map[string]any{"tenant": "acme", "owner": "7", "status": "shared"}, true},
+		{"private", map[string]any{"tenant": "acme", "owner": "7", "status": "draft"}, false},
+		{"other tenant", map[string]any{"tenant": "globex", "owner": "42"}, false},
+		{"missing attributes", map[string]any{"owner": "42"}, false},
+		{"different type", map[string]any{"tenant": "acme", "owner": 42}, false},
+	}
+
+	predicate := condition.Predicate()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := predicate(tt.attributes); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConditionToSQL(t *testing.T) {
+	condition := And(Eq("tenant", "acme"), Or(Eq("owner", "42"), In("status", "public", "shared")))
+
+	tests := []struct {
+		name      string
+		condition Condition
+		opts      SQLOptions
+		sql       string
+		args      []any
+		err       bool
+	}{
+		{"question", condition, SQLOptions{}, "tenant = ? AND (owner = ? OR status IN (?, ?))", []any{"acme", "42", "public", "shared"}, false},
+		{"dollar", condition, SQLOptions{Placeholder: DollarPlaceholder, FirstParam: 3}, "tenant = $3 AND (owner = $4 OR status IN ($5, $6))", []any{"acme", "42", "public", "shared"}, false},
+		{"columns", Eq("owner", "42"), SQLOptions{Columns: map[string]string{"owner": "d.owner_id"}}, "d.owner_id = ?", []any{"42"}, false},
+		{"true", True(), SQLOptions{}, "1 = 1", nil, false},
+		{"false", False(), SQLOptions{}, "1 = 0", nil, false},
+		{"invalid column", Eq("owner; DROP TABLE users", "42"), SQLOptions{}, "", nil, true},
+		{"invalid operator", Condition{Op: "like"}, SQLOptions{}, "", nil, true},
+		{"zero value", Condition{}, SQLOptions{}, "", nil, true},
+		{"eq without value", Condition{Op: EqOp, Attribute: "owner"}, SQLOptions{}, "", nil, true},
+		{"in without values", Condition{Op: InOp, Attribute: "status"}, SQLOptions{}, "", nil, true},
+		{"and without operands", Condition{Op: AndOp}, SQLOptions{}, "", nil, true},
+		{"or without operands", Condition{Op: OrOp}, SQLOptions{}, "", nil, true},
+		{"nested invalid operand", Condition{Op: OrOp, Operands: []Condition{Eq("owner", "42"), {Op: InOp, Attribute: "status"}}}, SQLOptions{}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.condition.ToSQL(tt.opts)
+			if (err != nil) != tt.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if sql != tt.sql || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Expected %q %v, got %q %v", tt.sql, tt.args, sql, args)
+			}
+		})
+	}
+}
+
+func TestConditionInvalidShapes(t *testing.T) {
+	tests := []struct {
+		condition Condition
+		expected  string
+	}{
+		{Condition{}, "<invalid condition >"},
+		{Condition{Op: EqOp, Attribute: "owner"}, "<invalid condition eq>"},
+		{Condition{Op: InOp, Attribute: "status"}, "<invalid condition in>"},
+		{Condition{Op: AndOp}, "<invalid condition and>"},
+		{Condition{Op: OrOp, Operands: []Condition{Eq("owner", "42"), {Op: AndOp}}}, `owner = "42" OR (<invalid condition and>)`},
+	}
+
+	for _, tt := range tests {
+		if s := tt.condition.String(); s != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, s)
+		}
+		if err := tt.condition.Validate(); err == nil {
+			t.Errorf("Expected %q to be invalid", tt.expected)
+		}
+	}
+
+	if err := And(Eq("tenant", "acme"), In("status", "public", "shared")).Validate(); err != nil {
+		t.Errorf("Expected valid condition, got %v", err)
+	}
+}
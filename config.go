@@ -0,0 +1,265 @@
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format of the host and schema configuration files.
+type ConfigFormat string
+
+const (
+	JSONConfigFormat ConfigFormat = "json"
+	// YAML is mapped onto the same structure as JSON, so field names are the same.
+	// Anchors, aliases and merge keys ("<<") are supported.
+	YAMLConfigFormat ConfigFormat = "yaml"
+	DSLConfigFormat  ConfigFormat = "dsl"
+)
+
+// Returns format of the configuration file by its extension.
+// Files with unknown extension are considered to be JSON.
+func ConfigFormatFromPath(path string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAMLConfigFormat
+	case DSLExtension:
+		return DSLConfigFormat
+	default:
+		return JSONConfigFormat
+	}
+}
+
+func (f ConfigFormat) Validate() error {
+	switch f {
+	case JSONConfigFormat, YAMLConfigFormat, DSLConfigFormat:
+		return nil
+	default:
+		return errors.New("config format \"" + string(f) + "\" doesn't exist")
+	}
+}
+
+// Converts YAML document into JSON, resolving all anchors and aliases.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// Converts JSON document into YAML, preserving order of the object keys.
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err := jsonToYAMLNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func jsonToYAMLNode(dec *json.Decoder) (*yaml.Node, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if token == '{' {
+			node.Kind = yaml.MappingNode
+		}
+
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+
+			value, err := jsonToYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+
+		// Closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token}, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: token.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(token)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// Either host or schema configuration.
+type rawConfig struct {
+	host   *rawHost
+	schema *rawSchema
+}
+
+func (c rawConfig) value() any {
+	if c.schema != nil {
+		return c.schema
+	}
+	return c.host
+}
+
+// Decodes host or schema configuration.
+// JSON and YAML configurations are considered to be host if they have "schemas" field.
+func decodeRawConfig(data []byte, format ConfigFormat) (rawConfig, error) {
+	switch format {
+	case DSLConfigFormat:
+		file, err := parseDSL(data)
+		if err != nil {
+			return rawConfig{}, err
+		}
+		if file.isSchema {
+			return rawConfig{schema: file.host.Schemas[0]}, nil
+		}
+		return rawConfig{host: file.host}, nil
+	case YAMLConfigFormat:
+		converted, err := yamlToJSON(data)
+		if err != nil {
+			return rawConfig{}, err
+		}
+		data = converted
+	case JSONConfigFormat:
+	default:
+		return rawConfig{}, format.Validate()
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return rawConfig{}, err
+	}
+
+	if _, ok := fields["schemas"]; ok {
+		host := &rawHost{}
+		if err := json.Unmarshal(data, host); err != nil {
+			return rawConfig{}, err
+		}
+		return rawConfig{host: host}, host.checkEntries()
+	}
+
+	schema := &rawSchema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return rawConfig{}, err
+	}
+	return rawConfig{schema: schema}, schema.checkEntries()
+}
+
+func encodeRawConfig(config rawConfig, format ConfigFormat) ([]byte, error) {
+	switch format {
+	case DSLConfigFormat:
+		if config.schema != nil {
+			return formatSchemaDSL(config.schema)
+		}
+		return formatHostDSL(config.host)
+	case JSONConfigFormat:
+		return json.MarshalIndent(config.value(), "", "    ")
+	case YAMLConfigFormat:
+		data, err := json.Marshal(config.value())
+		if err != nil {
+			return nil, err
+		}
+		return jsonToYAML(data)
+	default:
+		return nil, format.Validate()
+	}
+}
+
+// Converts host or schema configuration from one format into another.
+func ConvertConfig(data []byte, from ConfigFormat, to ConfigFormat) ([]byte, error) {
+	config, err := decodeRawConfig(data, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeRawConfig(config, to)
+}
+
+// Decodes raw host or schema in the specified format.
+func decode[R any](data []byte, format ConfigFormat, raw *R) error {
+	switch format {
+	case JSONConfigFormat:
+	case YAMLConfigFormat:
+		converted, err := yamlToJSON(data)
+		if err != nil {
+			return err
+		}
+		data = converted
+	case DSLConfigFormat:
+		return decodeDSL(data, raw)
+	default:
+		return format.Validate()
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(raw); err != nil {
+		return err
+	}
+
+	switch raw := any(raw).(type) {
+	case *rawHost:
+		return raw.checkEntries()
+	case **rawSchema:
+		if *raw == nil {
+			return errors.New("Schema configuration is null")
+		}
+		return (*raw).checkEntries()
+	}
+
+	return nil
+}
+
+func decodeDSL[R any](data []byte, raw *R) error {
+	switch raw := any(raw).(type) {
+	case *rawHost:
+		host, err := parseHostDSL(data)
+		if err != nil {
+			return err
+		}
+		*raw = *host
+	case **rawSchema:
+		schema, err := parseSchemaDSL(data)
+		if err != nil {
+			return err
+		}
+		*raw = schema
+	default:
+		return errors.New("DSL isn't supported for this type")
+	}
+
+	return nil
+}
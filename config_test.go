@@ -0,0 +1,216 @@
+package rbac
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testYAMLHost = `
+x-permissions:
+  all: &all
+    create: true
+    read: true
+    update: true
+    delete: true
+  self: &self
+    self-read: true
+    self-update: true
+
+default-roles: [user]
+roles:
+  - name: user
+    permissions: *self
+  - name: admin
+    permissions:
+      <<: [*all, *self]
+
+schemas:
+  - id: auth-service
+    resources: [cache]
+    entities:
+      - name: user
+        actions:
+          - name: delete
+            required-permissions: {delete: true}
+    action-gate-policy:
+      - for: [user]
+        having: [admin]
+        apply: require
+        doing: [delete]
+        on: cache
+`
+
+func TestConfigFormatFromPath(t *testing.T) {
+	tests := map[string]ConfigFormat{
+		"RBAC.json":      JSONConfigFormat,
+		"RBAC.yaml":      YAMLConfigFormat,
+		"conf/RBAC.YML":  YAMLConfigFormat,
+		"RBAC.rbac":      DSLConfigFormat,
+		"RBAC":           JSONConfigFormat,
+		"RBAC.conf.json": JSONConfigFormat,
+	}
+
+	for path, expected := range tests {
+		if format := ConfigFormatFromPath(path); format != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, format)
+		}
+	}
+}
+
+func TestLoadHostYAML(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "RBAC.yaml")
+	if err := os.WriteFile(path, []byte(testYAMLHost), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	host, err := LoadHost(path)
+	if err != nil {
+		t.Fatalf("Failed to load YAML host: %v", err)
+	}
+
+	schema := host.Schemas[0]
+
+	admin, err := schema.ParseRole("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CreatePermission | ReadPermission | UpdatePermission | DeletePermission | SelfReadPermission | SelfUpdatePermission
+	if admin.Permissions != expected {
+		t.Errorf("Expected merged admin permissions %08b, got %08b", expected, admin.Permissions)
+	}
+
+	user, _ := schema.ParseRole("user")
+	if user.Permissions != SelfReadPermission|SelfUpdatePermission {
+		t.Errorf("Expected aliased user permissions, got %08b", user.Permissions)
+	}
+
+	// Explicitly chosen format must take precedence over the extension
+	explicit := filepath.Join(dir, "RBAC.conf")
+	if err := os.WriteFile(explicit, []byte(testYAMLHost), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHost(explicit); err == nil {
+		t.Error("Expected error when YAML is parsed as JSON")
+	}
+	explicitHost, err := LoadHostFormat(explicit, YAMLConfigFormat)
+	if err != nil {
+		t.Fatalf("Failed to load host in explicit format: %v", err)
+	}
+	if !reflect.DeepEqual(host, explicitHost) {
+		t.Error("Host loaded in explicit format differs")
+	}
+
+	if _, err := LoadHostFormat(explicit, "toml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestLoadSchemaYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yml")
+	config := "id: svc\nroles:\n  - name: admin\n    permissions: {read: true}\nresources: [cache]\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatalf("Failed to load YAML schema: %v", err)
+	}
+	if schema.ID != "svc" || len(schema.Roles) != 1 || schema.Roles[0].Permissions != ReadPermission {
+		t.Errorf("Unexpected schema: %+v", schema)
+	}
+}
+
+func TestConvertConfig(t *testing.T) {
+	data, err := os.ReadFile("cmd/RBAC.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	yamlData, err := ConvertConfig(data, JSONConfigFormat, YAMLConfigFormat)
+	if err != nil {
+		t.Fatalf("Failed to convert JSON into YAML: %v", err)
+	}
+
+	jsonData, err := ConvertConfig(yamlData, YAMLConfigFormat, JSONConfigFormat)
+	if err != nil {
+		t.Fatalf("Failed to convert YAML into JSON: %v", err)
+	}
+
+	var original, result rawHost
+	if err := json.Unmarshal(data, &original); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(original, result) {
+		t.Errorf("Host changed after conversion:\n%s", yamlData)
+	}
+
+	// Strings which look like other YAML types must stay strings
+	schemaYAML, err := ConvertConfig([]byte(`{"id": "123", "resources": ["yes", "null"]}`), JSONConfigFormat, YAMLConfigFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaJSON, err := ConvertConfig(schemaYAML, YAMLConfigFormat, JSONConfigFormat)
+	if err != nil {
+		t.Fatalf("Failed to convert schema back: %v\n%s", err, schemaYAML)
+	}
+
+	var schema rawSchema
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.ID != "123" || !reflect.DeepEqual(schema.Resources, []string{"yes", "null"}) {
+		t.Errorf("Unexpected schema after conversion:\n%s", schemaYAML)
+	}
+}
+
+func TestConvertConfigNullEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"host roles", `{"roles": [null], "schemas": []}`},
+		{"schemas", `{"schemas": [null]}`},
+		{"host rules", `{"action-gate-policy": [null], "schemas": []}`},
+		{"cross-schema grants", `{"cross-schema-grants": [null], "schemas": []}`},
+		{"host suppressions", `{"suppress": [null], "schemas": []}`},
+		{"schema roles", `{"schemas": [{"id": "svc", "roles": [null]}]}`},
+		{"entities", `{"id": "svc", "entities": [null]}`},
+		{"actions", `{"id": "svc", "entities": [{"name": "user", "actions": [null]}]}`},
+		{"rules", `{"id": "svc", "action-gate-policy": [null]}`},
+		{"relations", `{"id": "svc", "relations": [null]}`},
+		{"relation type relations", `{"id": "svc", "relations": [{"type": "doc", "relations": [null]}]}`},
+		{"union", `{"id": "svc", "relations": [{"type": "doc", "relations": [{"name": "viewer", "rewrite": {"union": [null]}}]}]}`},
+		{"relation grants", `{"id": "svc", "relation-grants": [null]}`},
+		{"tenants", `{"id": "svc", "tenants": [null]}`},
+		{"tenant rules", `{"id": "svc", "tenants": [{"id": "acme", "action-gate-policy": [null]}]}`},
+		{"suppressions", `{"id": "svc", "suppress": [null]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, to := range []ConfigFormat{DSLConfigFormat, JSONConfigFormat, YAMLConfigFormat} {
+				if _, err := ConvertConfig([]byte(tt.config), JSONConfigFormat, to); err == nil {
+					t.Errorf("Expected error for null entry when converting into %s", to)
+				}
+			}
+		})
+	}
+
+	// Loading rejects such entries as well.
+	path := filepath.Join(t.TempDir(), "host.json")
+	if err := os.WriteFile(path, []byte(`{"schemas": [null]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHost(path); err == nil {
+		t.Error("Expected error for null schema")
+	}
+}
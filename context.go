@@ -4,6 +4,14 @@ type AuthorizationContext struct {
 	Entity   *Entity
 	Action   Action
 	Resource *Resource
+	// Optional. ID of the specific entity which performs an action (e.g. user ID).
+	// Used for relation based authorization.
+	Subject string
+	// Optional. ID of the specific resource on which action is performed.
+	// Used for relation based authorization.
+	ResourceID string
+	// Optional. Tenant (domain) in which action is performed.
+	Tenant string
 }
 
 func (ctx *AuthorizationContext) String() string {
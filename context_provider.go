@@ -0,0 +1,134 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Wrapped by errors of the context-aware rule providers, if authorizer fails closed (see FailurePolicy).
+var ErrRuleProviderFailed = errors.New("rule provider has failed")
+
+// ContextRuleProvider is a context-aware variant of the RuleProvider, for providers backed by database
+// or remote service: it receives context.Context to propagate deadlines and cancellation, and may fail.
+// Provider must return as soon as c is done.
+type ContextRuleProvider interface {
+	GetRuleContext(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error)
+}
+
+// ContextRuleProviderFunc is a ContextRuleProvider backed by a function.
+type ContextRuleProviderFunc func(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error)
+
+func (f ContextRuleProviderFunc) GetRuleContext(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error) {
+	return f(c, ctx)
+}
+
+type contextProvider struct {
+	provider RuleProvider
+}
+
+// Adapts RuleProvider to the ContextRuleProvider. Adapted provider never fails,
+// if it's MultiRuleProvider (e.g. AllProviders), then all its rules are still applied.
+func ContextProvider(provider RuleProvider) ContextRuleProvider {
+	return contextProvider{provider: provider}
+}
+
+func (p contextProvider) GetRuleContext(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error) {
+	if p.provider == nil {
+		return nil, false, nil
+	}
+	rule, ok := p.provider.GetRule(ctx)
+	return rule, ok, nil
+}
+
+// FailurePolicy defines result of the context-aware authorization when rule provider fails
+// (including exceeded deadline or cancellation of the context).
+type FailurePolicy string
+
+const (
+	// Action is denied with error which wraps both ErrRuleProviderFailed and error of the provider. Used by default.
+	FailClosedPolicy FailurePolicy = "closed"
+	// Error of the provider is ignored (it's logged via Debug), action is authorized as if provider doesn't have rule for it.
+	// Rule which was returned without error is always applied, even if it was returned after the deadline.
+	FailOpenPolicy FailurePolicy = "open"
+)
+
+func (p FailurePolicy) Validate() error {
+	switch p {
+	case FailClosedPolicy, FailOpenPolicy:
+		return nil
+	default:
+		return errors.New("failure policy \"" + string(p) + "\" doesn't exist")
+	}
+}
+
+// SetFailurePolicy sets failure policy of the context-aware authorization globally.
+func SetFailurePolicy(policy FailurePolicy) {
+	defaultAuthorizer.SetFailurePolicy(policy)
+}
+
+// SetFailurePolicy sets failure policy of the context-aware authorization for this authorizer.
+func (a *Authorizer) SetFailurePolicy(policy FailurePolicy) {
+	if err := policy.Validate(); err != nil {
+		panic(err.Error())
+	}
+	a.failurePolicy = policy
+}
+
+// Same as Authorize, but with context-aware rule provider (see ContextRuleProvider).
+// If provider fails, then result depends on the failure policy (see SetFailurePolicy).
+func AuthorizeContext(c context.Context, ctx *AuthorizationContext, roles []Role, provider ContextRuleProvider) error {
+	return defaultAuthorizer.AuthorizeContext(c, ctx, roles, provider)
+}
+
+// Same as Decide, but with context-aware rule provider (see ContextRuleProvider).
+func DecideContext(c context.Context, ctx *AuthorizationContext, roles []Role, provider ContextRuleProvider) Decision {
+	return defaultAuthorizer.DecideContext(c, ctx, roles, provider)
+}
+
+// AuthorizeContext checks authorization using provided context-aware rule provider.
+func (a *Authorizer) AuthorizeContext(c context.Context, ctx *AuthorizationContext, roles []Role, provider ContextRuleProvider) error {
+	return a.DecideContext(c, ctx, roles, provider).Err
+}
+
+// DecideContext checks authorization using provided context-aware rule provider and returns detailed result of it.
+// If provider fails and authorizer fails closed, then decision source is RuleProviderDecisionSource.
+func (a *Authorizer) DecideContext(c context.Context, ctx *AuthorizationContext, roles []Role, provider ContextRuleProvider) Decision {
+	merged := mergePermissions(roles)
+
+	return a.decideWith(ctx, roles, merged, func() Decision {
+		return a.decide(ctx, roles, merged, func() (*ActionGateRule, bool, error) {
+			return a.applyContextRules(c, ctx, roles, provider)
+		})
+	})
+}
+
+// Applies rule of the context-aware provider, failure of the provider is handled according to the failure policy.
+func (a *Authorizer) applyContextRules(c context.Context, ctx *AuthorizationContext, roles []Role, provider ContextRuleProvider) (*ActionGateRule, bool, error) {
+	if p, ok := provider.(contextProvider); ok {
+		return applyProviderRules(ctx, roles, p.provider)
+	}
+	if provider == nil {
+		return nil, false, nil
+	}
+
+	rule, ok, err := provider.GetRuleContext(c, ctx)
+	if err == nil && a.failurePolicy != FailOpenPolicy {
+		// Result which was returned after the deadline is not trusted, unless authorizer fails open:
+		// in that case the rule is still safer than authorization without it.
+		err = c.Err()
+	}
+	if err != nil {
+		if a.failurePolicy == FailOpenPolicy {
+			Debug.Log("Rule provider has failed, failing open: " + err.Error())
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%w: %w", ErrRuleProviderFailed, err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	bypass, err := rule.Apply(ctx.Action, roles)
+	return rule, bypass, err
+}
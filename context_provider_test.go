@@ -0,0 +1,140 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthorizeContext(t *testing.T) {
+	user := NewEntity("user")
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+	ctx := NewAuthorizationContext(&user, deleteAction, cache)
+
+	admin := NewRole("admin", DeletePermission)
+	support := NewRole("support", 0)
+
+	errLookup := errors.New("connection refused")
+
+	// Simulates remote lookup which takes the specified time and honors the context.
+	remote := func(delay time.Duration, effect ActionGateEffect, err error) ContextRuleProvider {
+		return ContextRuleProviderFunc(func(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error) {
+			select {
+			case <-time.After(delay):
+			case <-c.Done():
+				return nil, false, c.Err()
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			return NewActionGateRule(ctx, effect, []Role{admin}), true, nil
+		})
+	}
+
+	// Ignores the context and returns rule after the deadline.
+	slow := func(effect ActionGateEffect, role Role) ContextRuleProvider {
+		return ContextRuleProviderFunc(func(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error) {
+			time.Sleep(50 * time.Millisecond)
+			return NewActionGateRule(ctx, effect, []Role{role}), true, nil
+		})
+	}
+
+	agp := NewActionGatePolicy()
+	agp.AddRule(NewActionGateRule(&ctx, DenyActionGateEffect, []Role{admin}))
+
+	tests := []struct {
+		name        string
+		policy      FailurePolicy
+		provider    ContextRuleProvider
+		timeout     time.Duration
+		roles       []Role
+		expected    []error
+		source      DecisionSource
+		maxDuration time.Duration
+	}{
+		{"rule in time", FailClosedPolicy, remote(0, DenyActionGateEffect, nil), time.Second, []Role{admin}, []error{ErrActionDeniedByAGP}, ActionGatePolicyDecisionSource, time.Second},
+		{"no provider", FailClosedPolicy, nil, time.Second, []Role{admin}, nil, PermissionsDecisionSource, time.Second},
+		{"timeout fails closed", FailClosedPolicy, remote(time.Second, AllowActionGateEffect, nil), 20 * time.Millisecond, []Role{admin}, []error{ErrRuleProviderFailed, context.DeadlineExceeded}, RuleProviderDecisionSource, 500 * time.Millisecond},
+		{"timeout fails open", FailOpenPolicy, remote(time.Second, DenyActionGateEffect, nil), 20 * time.Millisecond, []Role{admin}, nil, PermissionsDecisionSource, 500 * time.Millisecond},
+		{"fail open still checks permissions", FailOpenPolicy, remote(time.Second, AllowActionGateEffect, nil), 20 * time.Millisecond, []Role{support}, []error{ErrInsufficientPermissions}, PermissionsDecisionSource, 500 * time.Millisecond},
+		{"lookup error fails closed", FailClosedPolicy, remote(0, AllowActionGateEffect, errLookup), time.Second, []Role{admin}, []error{ErrRuleProviderFailed, errLookup}, RuleProviderDecisionSource, time.Second},
+		{"late result isn't trusted", FailClosedPolicy, slow(AllowActionGateEffect, support), 10 * time.Millisecond, []Role{support}, []error{ErrRuleProviderFailed, context.DeadlineExceeded}, RuleProviderDecisionSource, time.Second},
+		{"late deny is honored when failing open", FailOpenPolicy, slow(DenyActionGateEffect, admin), 10 * time.Millisecond, []Role{admin}, []error{ErrActionDeniedByAGP}, ActionGatePolicyDecisionSource, time.Second},
+		{"adapted provider", FailClosedPolicy, ContextProvider(&agp), time.Second, []Role{admin}, []error{ErrActionDeniedByAGP}, ActionGatePolicyDecisionSource, time.Second},
+		{"adapted provider combinator", FailClosedPolicy, ContextProvider(AllProviders(nil, &agp)), time.Second, []Role{support}, []error{ErrInsufficientPermissions}, PermissionsDecisionSource, time.Second},
+		{"adapted nil provider", FailClosedPolicy, ContextProvider(nil), time.Second, []Role{admin}, nil, PermissionsDecisionSource, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := NewAuthorizer()
+			authorizer.SetFailurePolicy(tt.policy)
+
+			c, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			decision := authorizer.DecideContext(c, &ctx, tt.roles, tt.provider)
+			if elapsed := time.Since(start); elapsed > tt.maxDuration {
+				t.Errorf("Expected authorization to take less than %s, took %s", tt.maxDuration, elapsed)
+			}
+
+			if tt.expected == nil && decision.Err != nil {
+				t.Errorf("Expected action to be allowed, got %v", decision.Err)
+			}
+			for _, expected := range tt.expected {
+				if !errors.Is(decision.Err, expected) {
+					t.Errorf("Expected %v, got %v", expected, decision.Err)
+				}
+			}
+			if decision.Source != tt.source {
+				t.Errorf("Expected source %s, got %s", tt.source, decision.Source)
+			}
+		})
+	}
+}
+
+func TestAuthorizeContextCancellation(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	ctx := NewAuthorizationContext(&user, readAction, NewResource("cache"))
+	roles := []Role{NewRole("support", ReadPermission)}
+
+	provider := ContextRuleProviderFunc(func(c context.Context, ctx *AuthorizationContext) (*ActionGateRule, bool, error) {
+		<-c.Done()
+		return nil, false, c.Err()
+	})
+
+	c, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := AuthorizeContext(c, &ctx, roles, provider)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrRuleProviderFailed) {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+	if status, _ := HTTPStatus(err); status != 503 {
+		t.Errorf("Expected status 503, got %d", status)
+	}
+
+	// Interceptors are applied to the context-aware authorization as well.
+	authorizer := NewAuthorizer()
+	authorizer.AddInterceptors(Interceptor{
+		Before: func(*AuthorizationContext, []Role) *Decision {
+			return &Decision{Allowed: true}
+		},
+	})
+	if err := authorizer.AuthorizeContext(c, &ctx, roles, provider); err != nil {
+		t.Errorf("Expected interceptor to short-circuit authorization, got %v", err)
+	}
+}
+
+func TestSetFailurePolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unknown failure policy")
+		}
+	}()
+	NewAuthorizer().SetFailurePolicy("sometimes")
+}
@@ -0,0 +1,198 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrCrossSchemaAccessNotGranted = errors.New("cross-schema access hasn't been granted")
+
+// CrossSchemaGrant allows entities of one schema (e.g. "service" entity of the "billing" schema)
+// to perform actions on the resources of another schema (e.g. "user" resource of the "auth-service" schema).
+//
+// Grant applies to each combination of its entities, actions and resources.
+type CrossSchemaGrant struct {
+	// ID of the schema, which entities perform actions.
+	From     string
+	Entities []string
+	Actions  []Action
+	// ID of the schema, which resources are accessed.
+	To        string
+	Resources []string
+	// Roles of the From schema, caller must have at least one of them.
+	Roles []string
+}
+
+func (g *CrossSchemaGrant) matches(from string, entity string, act Action, to string, resource string) bool {
+	if g.From != from || g.To != to {
+		return false
+	}
+	if !containsString(g.Entities, entity) || !containsString(g.Resources, resource) {
+		return false
+	}
+	for _, a := range g.Actions {
+		if a == act {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *CrossSchemaGrant) grantedTo(roles []Role) bool {
+	for _, role := range roles {
+		if containsString(g.Roles, role.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validates that grant references existing schemas, entities, actions, resources and roles.
+func (g *rawCrossSchemaGrant) normalize(host *Host) (CrossSchemaGrant, error) {
+	var zero CrossSchemaGrant
+
+	if g.From == g.To {
+		return zero, errors.New("grant must be between different schemas, use Action Gate Policy within the schema")
+	}
+
+	from, fromIdx, ok := host.lookupSchema(g.From)
+	if !ok {
+		return zero, &NotFoundError{Kind: ErrSchemaNotFound, Name: g.From}
+	}
+	to, toIdx, ok := host.lookupSchema(g.To)
+	if !ok {
+		return zero, &NotFoundError{Kind: ErrSchemaNotFound, Name: g.To}
+	}
+
+	if len(g.For) == 0 || len(g.Doing) == 0 || len(g.On) == 0 || len(g.Having) == 0 {
+		return zero, errors.New("grant must have entities, actions, resources and roles")
+	}
+
+	grant := CrossSchemaGrant{
+		From:      g.From,
+		Entities:  g.For,
+		To:        g.To,
+		Resources: g.On,
+		Roles:     g.Having,
+	}
+
+	for _, name := range g.Doing {
+		grant.Actions = append(grant.Actions, Action(name))
+	}
+
+	for _, name := range g.For {
+		entity, err := from.lookupEntity(fromIdx, name)
+		if err != nil {
+			return zero, err
+		}
+		for _, act := range grant.Actions {
+			if _, ok := entity.GetRequiredActionPermissions(act); !ok {
+				return zero, errors.New("entity \"" + name + "\" doesn't have \"" + act.String() + "\" action")
+			}
+		}
+	}
+
+	for _, name := range g.On {
+		if _, err := to.lookupResource(toIdx, name); err != nil {
+			return zero, err
+		}
+	}
+
+	if _, err := from.lookupRoles(fromIdx, g.Having); err != nil {
+		return zero, err
+	}
+
+	return grant, nil
+}
+
+func normalizeCrossSchemaGrants(host *Host, rawGrants []*rawCrossSchemaGrant) ([]CrossSchemaGrant, error) {
+	var grants []CrossSchemaGrant
+
+	for _, rawGrant := range rawGrants {
+		grant, err := rawGrant.normalize(host)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cross-schema grant from %s to %s: %s", rawGrant.From, rawGrant.To, err.Error())
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
+}
+
+// Authorizes action of the entity from one schema on the resource of another schema.
+// Both sides are checked:
+//   - roles (of the "from" schema) must have permissions required by the action of the entity;
+//   - there must be a cross-schema grant for this entity, action and resource, and roles must include one of its roles.
+//
+// Action Gate Policies of the schemas aren't applied, since their rules refer only to their own resources.
+// Returns NotFoundError if any of the names doesn't exist and ErrCrossSchemaAccessNotGranted if there are no suitable grant.
+func (h *Host) AuthorizeCrossSchema(
+	from string,
+	entityName string,
+	act Action,
+	to string,
+	resourceName string,
+	roleNames []string,
+) error {
+	return h.DecideCrossSchema(from, entityName, act, to, resourceName, roleNames).Err
+}
+
+// Same as Host.AuthorizeCrossSchema, but returns detailed result of authorization.
+func (h *Host) DecideCrossSchema(
+	from string,
+	entityName string,
+	act Action,
+	to string,
+	resourceName string,
+	roleNames []string,
+) Decision {
+	a := h.authorizer()
+
+	fromSchema, fromIdx, ok := h.lookupSchema(from)
+	if !ok {
+		return a.decideNotFound(entityName, act, resourceName, &NotFoundError{Kind: ErrSchemaNotFound, Name: from})
+	}
+	toSchema, toIdx, ok := h.lookupSchema(to)
+	if !ok {
+		return a.decideNotFound(entityName, act, resourceName, &NotFoundError{Kind: ErrSchemaNotFound, Name: to})
+	}
+
+	entity, err := fromSchema.lookupEntity(fromIdx, entityName)
+	if err != nil {
+		return a.decideNotFound(entityName, act, resourceName, err)
+	}
+	resource, err := toSchema.lookupResource(toIdx, resourceName)
+	if err != nil {
+		return a.decideNotFound(entityName, act, resourceName, err)
+	}
+	roles, err := fromSchema.lookupRoles(fromIdx, roleNames)
+	if err != nil {
+		return a.decideNotFound(entityName, act, resourceName, err)
+	}
+
+	ctx := NewAuthorizationContext(entity, act, resource)
+	merged := mergePermissions(roles)
+
+	// Grant is checked inside of the evaluation, so interceptors observe the final decision.
+	return a.decideWith(&ctx, roles, merged, func() Decision {
+		decision := a.decide(&ctx, roles, merged, func() (*ActionGateRule, bool, error) {
+			return nil, false, nil
+		})
+		if decision.Err != nil {
+			return decision
+		}
+
+		for i := range h.CrossSchemaGrants {
+			grant := &h.CrossSchemaGrants[i]
+			if grant.matches(from, entityName, act, to, resourceName) && grant.grantedTo(roles) {
+				return decision
+			}
+		}
+
+		decision.Allowed = false
+		decision.Source = CrossSchemaGrantDecisionSource
+		decision.Err = ErrCrossSchemaAccessNotGranted
+
+		return decision
+	})
+}
@@ -0,0 +1,154 @@
+package rbac
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const crossSchemaTestHost = `{
+	"roles": [
+		{"name": "service", "permissions": {"read": true}},
+		{"name": "admin", "permissions": {"read": true, "update": true}}
+	],
+	"schemas": [
+		{
+			"id": "billing",
+			"roles": [{"name": "auditor", "permissions": {"read": true}}],
+			"entities": [
+				{"name": "service", "actions": [
+					{"name": "read", "required-permissions": {"read": true}},
+					{"name": "update", "required-permissions": {"update": true}}
+				]}
+			],
+			"resources": ["invoice"]
+		},
+		{
+			"id": "auth-service",
+			"entities": [{"name": "service", "actions": [{"name": "read", "required-permissions": {"read": true}}]}],
+			"resources": ["user", "session"]
+		}
+	],
+	"cross-schema-grants": [
+		{"from": "billing", "for": ["service"], "doing": ["read", "update"], "to": "auth-service", "on": ["user"], "having": ["service", "admin"]}
+	]
+}`
+
+func TestAuthorizeCrossSchema(t *testing.T) {
+	host, err := loadMergeTestHost(t, crossSchemaTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from     string
+		act      Action
+		to       string
+		resource string
+		roles    []string
+		expected error
+	}{
+		{"granted", "billing", "read", "auth-service", "user", []string{"service"}, nil},
+		{"granted to other role", "billing", "update", "auth-service", "user", []string{"admin"}, nil},
+		{"insufficient permissions", "billing", "update", "auth-service", "user", []string{"service"}, ErrInsufficientPermissions},
+		{"role isn't trusted", "billing", "read", "auth-service", "user", []string{"auditor"}, ErrCrossSchemaAccessNotGranted},
+		{"resource isn't granted", "billing", "read", "auth-service", "session", []string{"service"}, ErrCrossSchemaAccessNotGranted},
+		{"opposite direction", "auth-service", "read", "billing", "invoice", []string{"service"}, ErrCrossSchemaAccessNotGranted},
+		{"unknown action", "billing", "delete", "auth-service", "user", []string{"service"}, ErrEntityDoesNotHaveSuchAction},
+		{"unknown target schema", "billing", "read", "search", "user", []string{"service"}, ErrSchemaNotFound},
+		{"resource of caller schema", "billing", "read", "auth-service", "invoice", []string{"service"}, ErrResourceNotFound},
+		{"role of target schema", "auth-service", "read", "billing", "invoice", []string{"auditor"}, ErrRoleNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := host.AuthorizeCrossSchema(test.from, "service", test.act, test.to, test.resource, test.roles)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	decision := host.DecideCrossSchema("billing", "service", "read", "auth-service", "session", []string{"service"})
+	if decision.Allowed || decision.Source != CrossSchemaGrantDecisionSource {
+		t.Errorf("Unexpected decision: %+v", decision)
+	}
+}
+
+func TestCrossSchemaGrantValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		grant    string
+		expected string
+	}{
+		{"same schema", `{"from": "billing", "for": ["service"], "doing": ["read"], "to": "billing", "on": ["invoice"], "having": ["service"]}`, "different schemas"},
+		{"unknown schema", `{"from": "billing", "for": ["service"], "doing": ["read"], "to": "search", "on": ["user"], "having": ["service"]}`, `schema with id "search" wasn't found`},
+		{"missing roles", `{"from": "billing", "for": ["service"], "doing": ["read"], "to": "auth-service", "on": ["user"]}`, "must have"},
+		{"unknown entity", `{"from": "billing", "for": ["worker"], "doing": ["read"], "to": "auth-service", "on": ["user"], "having": ["service"]}`, `doesn't have entity "worker"`},
+		{"unknown action", `{"from": "billing", "for": ["service"], "doing": ["delete"], "to": "auth-service", "on": ["user"], "having": ["service"]}`, `doesn't have "delete" action`},
+		{"resource of caller", `{"from": "billing", "for": ["service"], "doing": ["read"], "to": "auth-service", "on": ["invoice"], "having": ["service"]}`, `doesn't have resource "invoice"`},
+		{"role of target", `{"from": "auth-service", "for": ["service"], "doing": ["read"], "to": "billing", "on": ["invoice"], "having": ["auditor"]}`, `doesn't have role "auditor"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := strings.Index(crossSchemaTestHost, `{"from"`)
+			end := strings.LastIndex(crossSchemaTestHost, "\n\t]")
+			config := crossSchemaTestHost[:start] + test.grant + crossSchemaTestHost[end:]
+
+			_, err := loadMergeTestHost(t, config)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestCrossSchemaGrantDSL(t *testing.T) {
+	src := `role service: read
+
+trust billing service:read, service:update on auth-service user, session for service
+
+schema billing {
+    resource invoice
+
+    entity service {
+        action read requires read
+        action update requires update
+    }
+}
+
+schema auth-service {
+    resource user, session
+}
+`
+	formatted, err := FormatDSL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != src {
+		t.Errorf("Expected:\n%s\nGot:\n%s", src, formatted)
+	}
+
+	host, err := ParseHostDSL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []CrossSchemaGrant{{
+		From:      "billing",
+		Entities:  []string{"service"},
+		Actions:   []Action{"read", "update"},
+		To:        "auth-service",
+		Resources: []string{"user", "session"},
+		Roles:     []string{"service"},
+	}}
+	if !reflect.DeepEqual(host.CrossSchemaGrants, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, host.CrossSchemaGrants)
+	}
+	if err := host.AuthorizeCrossSchema("billing", "service", "read", "auth-service", "session", []string{"service"}); err != nil {
+		t.Errorf("Expected access to be granted, got %v", err)
+	}
+}
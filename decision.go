@@ -0,0 +1,159 @@
+package rbac
+
+import "errors"
+
+// DecisionSource shows what determined the result of authorization.
+type DecisionSource string
+
+const (
+	// Result was determined by permissions of the roles.
+	PermissionsDecisionSource DecisionSource = "permissions"
+	// Result was determined by the Action Gate Policy rule.
+	ActionGatePolicyDecisionSource DecisionSource = "action-gate-policy"
+	// Action was granted (or failed to be granted) through relation.
+	RelationDecisionSource DecisionSource = "relation"
+	// Action was denied, since there are no suitable cross-schema grant (see CrossSchemaGrant).
+	CrossSchemaGrantDecisionSource DecisionSource = "cross-schema-grant"
+	// Authorization was short-circuited by the interceptor (see Interceptor).
+	InterceptorDecisionSource DecisionSource = "interceptor"
+	// Action was denied, since rule provider has failed and authorizer fails closed (see FailurePolicy).
+	RuleProviderDecisionSource DecisionSource = "rule-provider"
+)
+
+// Decision is a detailed result of authorization.
+type Decision struct {
+	Allowed bool
+	// Empty if authorization context is invalid (e.g. entity doesn't have such action).
+	Source DecisionSource
+	// Action Gate Policy rule which was found for the context, nil if there are no such rule.
+	// Note that rule may be found, but not affect the decision (e.g. "require" rule which was satisfied).
+	Rule *ActionGateRule
+	// Permissions required by the action.
+	Required Permissions
+	// Merged permissions of all roles.
+	Granted Permissions
+	// Nil if action is allowed. Authorization errors are wrapped into AuthorizationError.
+	Err error
+}
+
+// Same as Authorize, but returns detailed result of authorization.
+func Decide(ctx *AuthorizationContext, roles []Role, provider RuleProvider) Decision {
+	return defaultAuthorizer.Decide(ctx, roles, provider)
+}
+
+// Decide checks authorization using provided rule provider and returns detailed result of it.
+// Authorization errors are wrapped into AuthorizationError.
+func (a *Authorizer) Decide(ctx *AuthorizationContext, roles []Role, provider RuleProvider) Decision {
+	return a.decideMerged(ctx, roles, mergePermissions(roles), provider)
+}
+
+func mergePermissions(roles []Role) Permissions {
+	merged := Permissions(0)
+	for _, role := range roles {
+		merged |= role.Permissions
+	}
+	return merged
+}
+
+// Same as Decide, but with permissions of the roles already merged.
+func (a *Authorizer) decideMerged(ctx *AuthorizationContext, roles []Role, merged Permissions, provider RuleProvider) Decision {
+	return a.decideWith(ctx, roles, merged, func() Decision {
+		return a.decide(ctx, roles, merged, func() (*ActionGateRule, bool, error) {
+			return applyProviderRules(ctx, roles, provider)
+		})
+	})
+}
+
+// Applies rules of the provider for the context, see applyProviderRules.
+type rulesApplier func() (rule *ActionGateRule, bypass bool, err error)
+
+// Runs interceptors around the evaluation of the decision and wraps its error into AuthorizationError.
+// Evaluation must produce the final decision, so After hooks observe exactly what is returned to the caller.
+func (a *Authorizer) decideWith(ctx *AuthorizationContext, roles []Role, merged Permissions, evaluate func() Decision) Decision {
+	var decision Decision
+
+	intercepted, n := a.before(ctx, roles)
+	if intercepted != nil {
+		decision = *intercepted
+		if decision.Required == 0 {
+			decision.Required, _ = ctx.Entity.GetRequiredActionPermissions(ctx.Action)
+		}
+		if decision.Granted == 0 {
+			decision.Granted = merged
+		}
+	} else {
+		decision = evaluate()
+	}
+
+	if decision.Err != nil {
+		decision.Err = newAuthorizationError(ctx, &decision)
+	}
+
+	a.after(ctx, roles, decision, n)
+
+	return decision
+}
+
+func (a *Authorizer) decide(ctx *AuthorizationContext, roles []Role, mergredPermissions Permissions, applyRules rulesApplier) Decision {
+	var decision Decision
+
+	requiredPermissions, ok := ctx.Entity.GetRequiredActionPermissions(ctx.Action)
+	if !ok {
+		decision.Err = ErrEntityDoesNotHaveSuchAction
+		return decision
+	}
+
+	decision.Required = requiredPermissions
+	decision.Granted = mergredPermissions
+
+	rule, bypass, err := applyRules()
+	decision.Rule = rule
+	if err != nil {
+		decision.Source = ActionGatePolicyDecisionSource
+		if errors.Is(err, ErrRuleProviderFailed) {
+			decision.Source = RuleProviderDecisionSource
+		}
+		decision.Err = err
+		return decision
+	}
+	if bypass {
+		decision.Source = ActionGatePolicyDecisionSource
+		decision.Allowed = true
+		return decision
+	}
+
+	decision.Source = PermissionsDecisionSource
+
+	if err := a.authorizePermissions(ctx, roles, requiredPermissions, mergredPermissions); err != nil {
+		decision.Err = err
+
+		if a.relations == nil {
+			return decision
+		}
+
+		granted, relErr := a.relations.CheckContext(ctx)
+		if relErr != nil {
+			decision.Source = RelationDecisionSource
+			decision.Err = relErr
+			return decision
+		}
+		if !granted {
+			return decision
+		}
+
+		decision.Source = RelationDecisionSource
+		decision.Err = nil
+	}
+
+	decision.Allowed = true
+
+	return decision
+}
+
+// Uses strategy of the action or entity if it's set, otherwise authorization function of the authorizer.
+func (a *Authorizer) authorizePermissions(ctx *AuthorizationContext, roles []Role, required Permissions, permitted Permissions) error {
+	if s, ok := ctx.Entity.strategy(ctx.Action); ok {
+		return s.authorize(ctx, roles, required, permitted)
+	}
+	return a.authzFunc(required, permitted)
+}
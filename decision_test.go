@@ -0,0 +1,65 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+
+	adminRole := NewRole("admin", ReadPermission|DeletePermission)
+	userRole := NewRole("user", SelfReadPermission)
+
+	readCtx := NewAuthorizationContext(&user, readAction, cache)
+	deleteCtx := NewAuthorizationContext(&user, deleteAction, cache)
+
+	agp := NewActionGatePolicy()
+	agp.AddRule(NewActionGateRule(&deleteCtx, RequireActionGateEffect, []Role{adminRole}))
+
+	tests := []struct {
+		name           string
+		ctx            AuthorizationContext
+		roles          []Role
+		allowed        bool
+		source         DecisionSource
+		expectRule     bool
+		expectedErr    error
+		expectedGrants Permissions
+	}{
+		{"allowed by permissions", readCtx, []Role{adminRole}, true, PermissionsDecisionSource, false, nil, ReadPermission | DeletePermission},
+		{"denied by permissions", readCtx, []Role{userRole}, false, PermissionsDecisionSource, false, ErrInsufficientPermissions, SelfReadPermission},
+		{"denied by AGP", deleteCtx, []Role{userRole}, false, ActionGatePolicyDecisionSource, true, ErrActionDeniedByAGP, SelfReadPermission},
+		{"satisfied require rule", deleteCtx, []Role{adminRole}, true, PermissionsDecisionSource, true, nil, ReadPermission | DeletePermission},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Decide(&tt.ctx, tt.roles, &agp)
+
+			if decision.Allowed != tt.allowed {
+				t.Errorf("Expected allowed %v, got %v", tt.allowed, decision.Allowed)
+			}
+			if decision.Source != tt.source {
+				t.Errorf("Expected source %s, got %s", tt.source, decision.Source)
+			}
+			if (decision.Rule != nil) != tt.expectRule {
+				t.Errorf("Expected rule presence %v, got %v", tt.expectRule, decision.Rule)
+			}
+			if !errors.Is(decision.Err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, decision.Err)
+			}
+			if decision.Granted != tt.expectedGrants {
+				t.Errorf("Expected granted %d, got %d", tt.expectedGrants, decision.Granted)
+			}
+		})
+	}
+
+	invalidCtx := NewAuthorizationContext(&user, "update", cache)
+	if decision := Decide(&invalidCtx, []Role{adminRole}, nil); !errors.Is(decision.Err, ErrEntityDoesNotHaveSuchAction) || decision.Source != "" {
+		t.Errorf("Expected ErrEntityDoesNotHaveSuchAction without source, got %+v", decision)
+	}
+}
@@ -0,0 +1,332 @@
+// Package diff compares two versions of RBAC schemas or hosts and reports
+// what actually changed in access: roles, their permissions, Action Gate Policy rules
+// and, most importantly, authorization decisions which flipped between allow and deny.
+package diff
+
+import (
+	"sort"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+	"github.com/abaxoth0/SentinelRBAC/report"
+)
+
+type Change string
+
+const (
+	Added   Change = "added"
+	Removed Change = "removed"
+	Changed Change = "changed"
+)
+
+// RoleChange shows permissions gained and lost by a role which exists in both versions.
+type RoleChange struct {
+	Role   string   `json:"role"`
+	Gained []string `json:"gained,omitempty"`
+	Lost   []string `json:"lost,omitempty"`
+}
+
+type RoleDiff struct {
+	Added   []string     `json:"added,omitempty"`
+	Removed []string     `json:"removed,omitempty"`
+	Changed []RoleChange `json:"changed,omitempty"`
+}
+
+func (d RoleDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// RuleChange describes Action Gate Policy rule which was added, removed or changed.
+type RuleChange struct {
+	Context   string                `json:"context"`
+	Change    Change                `json:"change"`
+	OldEffect rbac.ActionGateEffect `json:"old-effect,omitempty"`
+	NewEffect rbac.ActionGateEffect `json:"new-effect,omitempty"`
+	OldRoles  []string              `json:"old-roles,omitempty"`
+	NewRoles  []string              `json:"new-roles,omitempty"`
+}
+
+// DecisionChange is an authorization decision for a single role which flipped between versions.
+// Role, context or action which doesn't exist in some version is considered as denied in it.
+type DecisionChange struct {
+	Role     string `json:"role"`
+	Entity   string `json:"entity"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	// What determined decision in the new version.
+	Reason string `json:"reason"`
+}
+
+func (c DecisionChange) Context() string {
+	return c.Entity + ":" + c.Action + ":" + c.Resource
+}
+
+type SchemaDiff struct {
+	ID        string           `json:"id"`
+	Roles     RoleDiff         `json:"roles"`
+	Rules     []RuleChange     `json:"rules,omitempty"`
+	Decisions []DecisionChange `json:"decisions,omitempty"`
+}
+
+func (d SchemaDiff) Empty() bool {
+	return d.Roles.Empty() && len(d.Rules) == 0 && len(d.Decisions) == 0
+}
+
+type HostDiff struct {
+	SchemasAdded   []string `json:"schemas-added,omitempty"`
+	SchemasRemoved []string `json:"schemas-removed,omitempty"`
+	GlobalRoles    RoleDiff `json:"global-roles"`
+	// Only schemas which exist in both versions and have changes.
+	Schemas []SchemaDiff `json:"schemas,omitempty"`
+}
+
+func (d HostDiff) Empty() bool {
+	return len(d.SchemasAdded) == 0 && len(d.SchemasRemoved) == 0 && d.GlobalRoles.Empty() && len(d.Schemas) == 0
+}
+
+func diffRoles(oldRoles []rbac.Role, newRoles []rbac.Role) RoleDiff {
+	var d RoleDiff
+
+	oldMap := make(map[string]rbac.Role, len(oldRoles))
+	for _, role := range oldRoles {
+		oldMap[role.Name] = role
+	}
+
+	newMap := make(map[string]rbac.Role, len(newRoles))
+	for _, role := range newRoles {
+		newMap[role.Name] = role
+
+		oldRole, ok := oldMap[role.Name]
+		if !ok {
+			d.Added = append(d.Added, role.Name)
+			continue
+		}
+
+		if oldRole.Permissions != role.Permissions {
+			d.Changed = append(d.Changed, RoleChange{
+				Role:   role.Name,
+				Gained: (role.Permissions &^ oldRole.Permissions).Names(),
+				Lost:   (oldRole.Permissions &^ role.Permissions).Names(),
+			})
+		}
+	}
+
+	for _, role := range oldRoles {
+		if _, ok := newMap[role.Name]; !ok {
+			d.Removed = append(d.Removed, role.Name)
+		}
+	}
+
+	return d
+}
+
+func ruleKey(rule *rbac.ActionGateRule) string {
+	return rule.Entity.Name() + ":" + rule.Action.String() + ":" + rule.Resource.Name()
+}
+
+func sameRoles(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func diffRules(oldPolicy rbac.ActionGatePolicy, newPolicy rbac.ActionGatePolicy) []RuleChange {
+	var changes []RuleChange
+
+	oldRules := map[string]*rbac.ActionGateRule{}
+	for _, rule := range oldPolicy.Rules() {
+		oldRules[ruleKey(rule)] = rule
+	}
+
+	newRules := map[string]*rbac.ActionGateRule{}
+	for _, rule := range newPolicy.Rules() {
+		key := ruleKey(rule)
+		newRules[key] = rule
+
+		oldRule, ok := oldRules[key]
+		if !ok {
+			changes = append(changes, RuleChange{
+				Context:   key,
+				Change:    Added,
+				NewEffect: rule.Effect,
+				NewRoles:  rbac.GetRolesNames(rule.Roles),
+			})
+			continue
+		}
+
+		oldRoles := rbac.GetRolesNames(oldRule.Roles)
+		newRoles := rbac.GetRolesNames(rule.Roles)
+
+		if oldRule.Effect != rule.Effect || !sameRoles(oldRoles, newRoles) {
+			changes = append(changes, RuleChange{
+				Context:   key,
+				Change:    Changed,
+				OldEffect: oldRule.Effect,
+				NewEffect: rule.Effect,
+				OldRoles:  oldRoles,
+				NewRoles:  newRoles,
+			})
+		}
+	}
+
+	for _, rule := range oldPolicy.Rules() {
+		key := ruleKey(rule)
+		if _, ok := newRules[key]; !ok {
+			changes = append(changes, RuleChange{
+				Context:   key,
+				Change:    Removed,
+				OldEffect: rule.Effect,
+				OldRoles:  rbac.GetRolesNames(rule.Roles),
+			})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Context < changes[j].Context
+	})
+
+	return changes
+}
+
+type decisionKey struct {
+	role    string
+	context string
+}
+
+func collectDecisions(matrix report.Matrix) (map[decisionKey]report.Cell, map[string]rbac.PermittedAction) {
+	cells := map[decisionKey]report.Cell{}
+	contexts := map[string]rbac.PermittedAction{}
+
+	for _, row := range matrix.Rows {
+		context := row.Context.String()
+		contexts[context] = row.Context
+
+		for _, cell := range row.Cells {
+			cells[decisionKey{role: cell.Role, context: context}] = cell
+		}
+	}
+
+	return cells, contexts
+}
+
+func diffDecisions(oldSchema *rbac.Schema, newSchema *rbac.Schema) []DecisionChange {
+	oldCells, oldContexts := collectDecisions(report.FromSchema(oldSchema))
+	newCells, newContexts := collectDecisions(report.FromSchema(newSchema))
+
+	contexts := make([]rbac.PermittedAction, 0, len(newContexts))
+	for _, context := range newContexts {
+		contexts = append(contexts, context)
+	}
+	for key, context := range oldContexts {
+		if _, ok := newContexts[key]; !ok {
+			contexts = append(contexts, context)
+		}
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].String() < contexts[j].String()
+	})
+
+	roles := rbac.GetRolesNames(newSchema.Roles)
+	for _, role := range oldSchema.Roles {
+		if _, err := newSchema.ParseRole(role.Name); err != nil {
+			roles = append(roles, role.Name)
+		}
+	}
+
+	var changes []DecisionChange
+
+	for _, context := range contexts {
+		for _, role := range roles {
+			key := decisionKey{role: role, context: context.String()}
+
+			oldCell := oldCells[key]
+			newCell := newCells[key]
+
+			if oldCell.Allowed == newCell.Allowed {
+				continue
+			}
+
+			change := DecisionChange{
+				Role:     role,
+				Entity:   context.Entity,
+				Action:   context.Action.String(),
+				Resource: context.Resource,
+				Before:   "deny",
+				After:    newCell.Decision(),
+			}
+			if oldCell.Allowed {
+				change.Before = "allow"
+			}
+			if newCell.Role != "" {
+				change.Reason = newCell.Reason()
+			} else {
+				change.Reason = "removed"
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// Compares two versions of the schema.
+func Schemas(oldSchema *rbac.Schema, newSchema *rbac.Schema) SchemaDiff {
+	return SchemaDiff{
+		ID:        newSchema.ID,
+		Roles:     diffRoles(oldSchema.Roles, newSchema.Roles),
+		Rules:     diffRules(oldSchema.ActionGatePolicy, newSchema.ActionGatePolicy),
+		Decisions: diffDecisions(oldSchema, newSchema),
+	}
+}
+
+// Compares two versions of the host.
+// Schemas are matched by their IDs, schemas which exist only in one version are reported as added or removed.
+func Hosts(oldHost *rbac.Host, newHost *rbac.Host) HostDiff {
+	d := HostDiff{
+		GlobalRoles: diffRoles(oldHost.GlobalRoles, newHost.GlobalRoles),
+	}
+
+	oldSchemas := make(map[string]*rbac.Schema, len(oldHost.Schemas))
+	for i := range oldHost.Schemas {
+		oldSchemas[oldHost.Schemas[i].ID] = &oldHost.Schemas[i]
+	}
+
+	newSchemas := make(map[string]bool, len(newHost.Schemas))
+	for i := range newHost.Schemas {
+		newSchema := &newHost.Schemas[i]
+		newSchemas[newSchema.ID] = true
+
+		oldSchema, ok := oldSchemas[newSchema.ID]
+		if !ok {
+			d.SchemasAdded = append(d.SchemasAdded, newSchema.ID)
+			continue
+		}
+
+		if schemaDiff := Schemas(oldSchema, newSchema); !schemaDiff.Empty() {
+			d.Schemas = append(d.Schemas, schemaDiff)
+		}
+	}
+
+	for _, schema := range oldHost.Schemas {
+		if !newSchemas[schema.ID] {
+			d.SchemasRemoved = append(d.SchemasRemoved, schema.ID)
+		}
+	}
+
+	return d
+}
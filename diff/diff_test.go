@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+const oldHostConfig = `{
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true}},
+		{"name": "moderator", "permissions": {"read": true, "update": true}},
+		{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}}
+	],
+	"schemas": [
+		{
+			"id": "auth",
+			"resources": ["cache"],
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"action-gate-policy": [
+				{"for": ["user"], "having": ["moderator"], "apply": "deny", "doing": ["read"], "on": "cache"}
+			]
+		},
+		{"id": "legacy"}
+	]
+}`
+
+const newHostConfig = `{
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true}},
+		{"name": "moderator", "permissions": {"read": true, "delete": true}},
+		{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}},
+		{"name": "auditor", "permissions": {"read": true}}
+	],
+	"schemas": [
+		{
+			"id": "auth",
+			"resources": ["cache"],
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"action-gate-policy": [
+				{"for": ["user"], "having": ["admin"], "apply": "require", "doing": ["delete"], "on": "cache"}
+			]
+		},
+		{"id": "billing"}
+	]
+}`
+
+func loadTestHost(t *testing.T, config string) rbac.Host {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "RBAC.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	host, err := rbac.LoadHost(path)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	return host
+}
+
+func TestHosts(t *testing.T) {
+	oldHost := loadTestHost(t, oldHostConfig)
+	newHost := loadTestHost(t, newHostConfig)
+
+	d := Hosts(&oldHost, &newHost)
+
+	if len(d.SchemasAdded) != 1 || d.SchemasAdded[0] != "billing" {
+		t.Errorf("Expected billing schema to be added, got %v", d.SchemasAdded)
+	}
+	if len(d.SchemasRemoved) != 1 || d.SchemasRemoved[0] != "legacy" {
+		t.Errorf("Expected legacy schema to be removed, got %v", d.SchemasRemoved)
+	}
+	if len(d.GlobalRoles.Added) != 1 || d.GlobalRoles.Added[0] != "auditor" {
+		t.Errorf("Expected auditor role to be added, got %v", d.GlobalRoles.Added)
+	}
+
+	if len(d.Schemas) != 1 {
+		t.Fatalf("Expected 1 changed schema, got %d", len(d.Schemas))
+	}
+
+	schema := d.Schemas[0]
+
+	if len(schema.Roles.Changed) != 1 {
+		t.Fatalf("Expected 1 changed role, got %v", schema.Roles.Changed)
+	}
+	moderator := schema.Roles.Changed[0]
+	if moderator.Role != "moderator" ||
+		strings.Join(moderator.Gained, ",") != "delete" ||
+		strings.Join(moderator.Lost, ",") != "update" {
+		t.Errorf("Unexpected moderator change %+v", moderator)
+	}
+
+	if len(schema.Rules) != 2 {
+		t.Fatalf("Expected 2 rule changes, got %v", schema.Rules)
+	}
+	if schema.Rules[0].Context != "user:delete:cache" || schema.Rules[0].Change != Added {
+		t.Errorf("Expected added rule for user:delete:cache, got %+v", schema.Rules[0])
+	}
+	if schema.Rules[1].Context != "user:read:cache" || schema.Rules[1].Change != Removed {
+		t.Errorf("Expected removed rule for user:read:cache, got %+v", schema.Rules[1])
+	}
+
+	// Moderator also gained delete permission, but new require rule allows it only for admin,
+	// so there must be no decision change for user:delete:cache.
+	expected := []string{
+		"moderator user:read:cache: deny -> allow (permissions)",
+		"auditor user:read:cache: deny -> allow (permissions)",
+	}
+	if len(schema.Decisions) != len(expected) {
+		t.Fatalf("Expected %d decision changes, got %+v", len(expected), schema.Decisions)
+	}
+	for i, decision := range schema.Decisions {
+		got := decision.Role + " " + decision.Context() + ": " + decision.Before + " -> " + decision.After + " (" + decision.Reason + ")"
+		if got != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], got)
+		}
+	}
+}
+
+func TestSchemasWithoutChanges(t *testing.T) {
+	host := loadTestHost(t, oldHostConfig)
+
+	if d := Hosts(&host, &host); !d.Empty() {
+		t.Errorf("Expected no changes, got %+v", d)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, HostDiff{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "No changes\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
+
+func TestRemovedRole(t *testing.T) {
+	user := rbac.NewEntity("user")
+	user.NewAction("read", rbac.ReadPermission)
+	cache := rbac.NewResource("cache")
+
+	oldSchema := rbac.NewSchema("svc", []rbac.Role{rbac.NewRole("reader", rbac.ReadPermission)}, nil, rbac.NewActionGatePolicy())
+	oldSchema.Entities = []rbac.Entity{user}
+	oldSchema.Resources = []rbac.Resource{*cache}
+
+	newSchema := oldSchema
+	newSchema.Roles = nil
+
+	d := Schemas(&oldSchema, &newSchema)
+
+	if len(d.Roles.Removed) != 1 || d.Roles.Removed[0] != "reader" {
+		t.Errorf("Expected reader role to be removed, got %v", d.Roles.Removed)
+	}
+	if len(d.Decisions) != 1 || d.Decisions[0].After != "deny" || d.Decisions[0].Reason != "removed" {
+		t.Errorf("Expected decision of removed role to flip to deny, got %+v", d.Decisions)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	oldHost := loadTestHost(t, oldHostConfig)
+	newHost := loadTestHost(t, newHostConfig)
+
+	d := Hosts(&oldHost, &newHost)
+
+	var text bytes.Buffer
+	if err := WriteText(&text, d); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		"+ schema billing",
+		"- schema legacy",
+		"Global roles:\n  + role auditor",
+		"Schema auth:",
+		"  ~ role moderator: gained delete; lost update;",
+		"  + rule user:delete:cache: require (admin)",
+		"  - rule user:read:cache: deny (moderator)",
+		"    auditor user:read:cache: deny -> allow (permissions)",
+	} {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("Expected output to contain %q, got:\n%s", s, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded HostDiff
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v", err)
+	}
+	if len(decoded.Schemas) != 1 || len(decoded.Schemas[0].Decisions) != 2 {
+		t.Errorf("Unexpected decoded diff %+v", decoded)
+	}
+}
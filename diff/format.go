@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+func writeRoleDiff(b *strings.Builder, indent string, d RoleDiff) {
+	for _, name := range d.Added {
+		b.WriteString(indent + "+ role " + name + "\n")
+	}
+	for _, name := range d.Removed {
+		b.WriteString(indent + "- role " + name + "\n")
+	}
+	for _, change := range d.Changed {
+		b.WriteString(indent + "~ role " + change.Role + ":")
+		if len(change.Gained) > 0 {
+			b.WriteString(" gained " + strings.Join(change.Gained, ", ") + ";")
+		}
+		if len(change.Lost) > 0 {
+			b.WriteString(" lost " + strings.Join(change.Lost, ", ") + ";")
+		}
+		b.WriteString("\n")
+	}
+}
+
+func formatRule(effect string, roles []string) string {
+	return effect + " (" + strings.Join(roles, ", ") + ")"
+}
+
+// Writes diff in human-readable form.
+func WriteText(w io.Writer, d HostDiff) error {
+	var b strings.Builder
+
+	if d.Empty() {
+		b.WriteString("No changes\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	for _, id := range d.SchemasAdded {
+		b.WriteString("+ schema " + id + "\n")
+	}
+	for _, id := range d.SchemasRemoved {
+		b.WriteString("- schema " + id + "\n")
+	}
+
+	if !d.GlobalRoles.Empty() {
+		b.WriteString("Global roles:\n")
+		writeRoleDiff(&b, "  ", d.GlobalRoles)
+	}
+
+	for _, schema := range d.Schemas {
+		b.WriteString("Schema " + schema.ID + ":\n")
+
+		writeRoleDiff(&b, "  ", schema.Roles)
+
+		for _, rule := range schema.Rules {
+			switch rule.Change {
+			case Added:
+				b.WriteString("  + rule " + rule.Context + ": " + formatRule(string(rule.NewEffect), rule.NewRoles) + "\n")
+			case Removed:
+				b.WriteString("  - rule " + rule.Context + ": " + formatRule(string(rule.OldEffect), rule.OldRoles) + "\n")
+			case Changed:
+				b.WriteString(
+					"  ~ rule " + rule.Context + ": " +
+						formatRule(string(rule.OldEffect), rule.OldRoles) + " -> " +
+						formatRule(string(rule.NewEffect), rule.NewRoles) + "\n",
+				)
+			}
+		}
+
+		if len(schema.Decisions) > 0 {
+			b.WriteString("  Access changes:\n")
+		}
+		for _, decision := range schema.Decisions {
+			b.WriteString(
+				"    " + decision.Role + " " + decision.Context() + ": " +
+					decision.Before + " -> " + decision.After + " (" + decision.Reason + ")\n",
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes diff as indented JSON.
+func WriteJSON(w io.Writer, d HostDiff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(d)
+}
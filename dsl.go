@@ -0,0 +1,939 @@
+package rbac
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Extension of the files which are written in the RBAC policy DSL.
+//
+// DSL is a line based alternative to the JSON configuration:
+//
+//	# Global roles of the host
+//	role user: self-read, self-update
+//	role admin: read, update, delete
+//	default-roles: user
+//
+//	schema auth-service {
+//	    role support: read, self-update
+//	    resource cache, user
+//
+//	    entity user {
+//	        action delete requires delete
+//	        action ping
+//	    }
+//
+//	    deny user:delete on cache unless admin
+//	    deny user:delete on user for support
+//	    allow user:delete on user for admin
+//	}
+//
+// File which consists of a single schema block is a schema file, any other file is a host file.
+const DSLExtension = ".rbac"
+
+// DSLError is a syntax or reference error in the DSL source.
+type DSLError struct {
+	Line    int
+	Message string
+}
+
+func (e *DSLError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func dslErrorf(line int, format string, args ...any) *DSLError {
+	return &DSLError{
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+type dslLine struct {
+	number int
+	tokens []string
+}
+
+// Splits line on tokens. Commas, braces and colons which are followed by whitespace are separate tokens,
+// '#' at the start of the token begins comment.
+func tokenizeDSLLine(line string) []string {
+	var tokens []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && word.Len() == 0:
+			return tokens
+		case c == ',' || c == '{' || c == '}':
+			flush()
+			tokens = append(tokens, string(c))
+		case c == ':' && (i+1 == len(line) || strings.IndexByte(" \t\r,", line[i+1]) != -1):
+			flush()
+			tokens = append(tokens, ":")
+		default:
+			word.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return tokens
+}
+
+func tokenizeDSL(data []byte) ([]dslLine, error) {
+	var lines []dslLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		if tokens := tokenizeDSLLine(scanner.Text()); len(tokens) > 0 {
+			lines = append(lines, dslLine{number, tokens})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// Parses comma separated list of names.
+func parseDSLList(line int, tokens []string, what string) ([]string, error) {
+	if len(tokens) == 0 {
+		return nil, dslErrorf(line, "%s are missing", what)
+	}
+
+	names := make([]string, 0, (len(tokens)+1)/2)
+
+	for i, token := range tokens {
+		if i%2 == 1 {
+			if token != "," {
+				return nil, dslErrorf(line, "expected \",\" between %s, got \"%s\"", what, token)
+			}
+			continue
+		}
+		if token == "," || token == ":" || token == "{" || token == "}" {
+			return nil, dslErrorf(line, "unexpected \"%s\" in %s", token, what)
+		}
+		names = append(names, token)
+	}
+
+	if len(tokens)%2 == 0 {
+		return nil, dslErrorf(line, "unexpected \",\" at the end of %s", what)
+	}
+
+	return names, nil
+}
+
+func parseDSLPermissions(line int, tokens []string) (*rawPermissions, error) {
+	if len(tokens) == 0 {
+		return newRawPermissions(0), nil
+	}
+
+	names, err := parseDSLList(line, tokens, "permissions")
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions Permissions
+
+	for _, name := range names {
+		permission, ok := parsePermissionName(name)
+		if !ok {
+			return nil, dslErrorf(line, "unknown permission \"%s\"", name)
+		}
+		permissions |= permission
+	}
+
+	return newRawPermissions(permissions), nil
+}
+
+type dslTarget struct {
+	entity string
+	action string
+}
+
+func parseDSLTargets(line int, tokens []string) ([]dslTarget, error) {
+	names, err := parseDSLList(line, tokens, "entity:action pairs")
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]dslTarget, len(names))
+
+	for i, name := range names {
+		entity, action, ok := strings.Cut(name, ":")
+		if !ok || entity == "" || action == "" || strings.Contains(action, ":") {
+			return nil, dslErrorf(line, "invalid target \"%s\", expected \"entity:action\"", name)
+		}
+		targets[i] = dslTarget{entity, action}
+	}
+
+	return targets, nil
+}
+
+// Groups targets into as few "for"/"doing" pairs as possible.
+// If targets form the cartesian product of their entities and actions then single pair is enough,
+// otherwise each target gets its own pair.
+func groupDSLTargets(targets []dslTarget) (entities [][]string, actions [][]string) {
+	var uniqueEntities, uniqueActions []string
+	seen := map[dslTarget]bool{}
+
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		if !containsString(uniqueEntities, target.entity) {
+			uniqueEntities = append(uniqueEntities, target.entity)
+		}
+		if !containsString(uniqueActions, target.action) {
+			uniqueActions = append(uniqueActions, target.action)
+		}
+	}
+
+	if len(seen) == len(uniqueEntities)*len(uniqueActions) {
+		return [][]string{uniqueEntities}, [][]string{uniqueActions}
+	}
+
+	for _, target := range targets {
+		entities = append(entities, []string{target.entity})
+		actions = append(actions, []string{target.action})
+	}
+
+	return entities, actions
+}
+
+type dslParser struct {
+	lines []dslLine
+	pos   int
+	host  *rawHost
+	// Number of statements outside of the schema blocks.
+	hostStatements int
+	// Reference checks, which are performed after the whole file was parsed,
+	// since names can be used before they are declared.
+	checks []func() error
+}
+
+func (p *dslParser) next() (dslLine, bool) {
+	if p.pos >= len(p.lines) {
+		return dslLine{}, false
+	}
+	line := p.lines[p.pos]
+	p.pos++
+	return line, true
+}
+
+// Checks that line is "<keyword> <name> {" and returns the name.
+func parseDSLBlockHeader(line dslLine) (string, error) {
+	if len(line.tokens) != 3 || line.tokens[2] != "{" {
+		return "", dslErrorf(line.number, "expected \"%s <name> {\"", line.tokens[0])
+	}
+	return line.tokens[1], nil
+}
+
+// Calls fn for each line of the block until closing brace.
+func (p *dslParser) parseBlock(start dslLine, fn func(line dslLine) error) error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			return dslErrorf(start.number, "\"%s\" block isn't closed", start.tokens[0])
+		}
+		if line.tokens[0] == "}" {
+			if len(line.tokens) != 1 {
+				return dslErrorf(line.number, "unexpected \"%s\" after \"}\"", line.tokens[1])
+			}
+			return nil
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *dslParser) parseHost() error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			return nil
+		}
+
+		switch line.tokens[0] {
+		case "schema":
+			if err := p.parseSchema(line); err != nil {
+				return err
+			}
+			continue
+		case "role":
+			role, err := parseDSLRole(line)
+			if err != nil {
+				return err
+			}
+			if err := checkDSLDuplicateRole(line, p.host.GlobalRoles, role.Name); err != nil {
+				return err
+			}
+			p.host.GlobalRoles = append(p.host.GlobalRoles, role)
+		case "default-roles":
+			names, err := parseDSLDefaultRoles(line)
+			if err != nil {
+				return err
+			}
+			p.host.DefaultRolesNames = append(p.host.DefaultRolesNames, names...)
+			p.checks = append(p.checks, func() error {
+				return checkDSLRoles(line.number, names, p.host.GlobalRoles, nil)
+			})
+		case "suppress":
+			suppression, err := parseDSLSuppression(line)
+			if err != nil {
+				return err
+			}
+			p.host.Suppress = append(p.host.Suppress, suppression)
+		case "role-merge":
+			merge, err := parseDSLRoleMerge(line)
+			if err != nil {
+				return err
+			}
+			p.host.RoleMerge = merge
+		case "deny", "allow":
+			rules, err := p.parseRule(line, nil)
+			if err != nil {
+				return err
+			}
+			p.host.ActionGatePolicy = append(p.host.ActionGatePolicy, rules...)
+		case "action-gate-policy-missing":
+			if len(line.tokens) != 3 || line.tokens[1] != ":" {
+				return dslErrorf(line.number, "expected \"action-gate-policy-missing: error|skip\"")
+			}
+			if err := MissingReferencesPolicy(line.tokens[2]).Validate(); err != nil {
+				return dslErrorf(line.number, "%s", err.Error())
+			}
+			p.host.MissingReferences = line.tokens[2]
+		case "trust":
+			grants, err := parseDSLTrust(line)
+			if err != nil {
+				return err
+			}
+			p.host.CrossSchemaGrants = append(p.host.CrossSchemaGrants, grants...)
+		case "include":
+			patterns, err := parseDSLList(line.number, line.tokens[1:], "include patterns")
+			if err != nil {
+				return err
+			}
+			p.host.Include = append(p.host.Include, patterns...)
+		default:
+			return dslErrorf(line.number, "unexpected \"%s\"", line.tokens[0])
+		}
+
+		p.hostStatements++
+	}
+}
+
+// Parses "trust <schema> <entity:action>, ... on <schema> <resource>, ... for <role>, ...".
+// References are checked when host is normalized, since schemas may be included from other files.
+func parseDSLTrust(line dslLine) ([]*rawCrossSchemaGrant, error) {
+	tokens := line.tokens
+	usage := dslErrorf(line.number, "expected \"trust <schema> <entity:action>, ... on <schema> <resource>, ... for <role>, ...\"")
+
+	on := indexOfString(tokens, "on")
+	forIdx := indexOfString(tokens, "for")
+	if on < 3 || forIdx < on+3 || forIdx+1 >= len(tokens) {
+		return nil, usage
+	}
+
+	targets, err := parseDSLTargets(line.number, tokens[2:on])
+	if err != nil {
+		return nil, err
+	}
+	resources, err := parseDSLList(line.number, tokens[on+2:forIdx], "resources")
+	if err != nil {
+		return nil, err
+	}
+	roles, err := parseDSLList(line.number, tokens[forIdx+1:], "roles")
+	if err != nil {
+		return nil, err
+	}
+
+	entities, actions := groupDSLTargets(targets)
+
+	grants := make([]*rawCrossSchemaGrant, len(entities))
+	for i := range entities {
+		grants[i] = &rawCrossSchemaGrant{
+			From:   tokens[1],
+			For:    entities[i],
+			Doing:  actions[i],
+			To:     tokens[on+1],
+			On:     resources,
+			Having: roles,
+		}
+	}
+
+	return grants, nil
+}
+
+// Parses "role <name> [(<merge strategy>)][: <permission>, ...]".
+func parseDSLRole(line dslLine) (*rawRole, error) {
+	tokens := line.tokens
+	if len(tokens) < 2 || tokens[1] == ":" {
+		return nil, dslErrorf(line.number, "role name is missing")
+	}
+
+	var merge string
+	if len(tokens) > 2 && strings.HasPrefix(tokens[2], "(") && strings.HasSuffix(tokens[2], ")") {
+		merge = strings.TrimSuffix(strings.TrimPrefix(tokens[2], "("), ")")
+		if err := MergeStrategy(merge).Validate(); err != nil {
+			return nil, dslErrorf(line.number, "%s", err.Error())
+		}
+		tokens = append([]string{tokens[0], tokens[1]}, tokens[3:]...)
+	}
+
+	if len(tokens) > 2 && tokens[2] != ":" {
+		return nil, dslErrorf(line.number, "expected \":\" after the role name, got \"%s\"", tokens[2])
+	}
+
+	var permissions *rawPermissions
+	var err error
+	if len(tokens) > 3 {
+		permissions, err = parseDSLPermissions(line.number, tokens[3:])
+	} else {
+		permissions, err = parseDSLPermissions(line.number, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawRole{
+		Name:        tokens[1],
+		Permissions: permissions,
+		Merge:       merge,
+	}, nil
+}
+
+func parseDSLRoleMerge(line dslLine) (string, error) {
+	if len(line.tokens) != 3 || line.tokens[1] != ":" {
+		return "", dslErrorf(line.number, "expected \"role-merge: <strategy>\"")
+	}
+	if err := MergeStrategy(line.tokens[2]).Validate(); err != nil {
+		return "", dslErrorf(line.number, "%s", err.Error())
+	}
+	return line.tokens[2], nil
+}
+
+func parseDSLMatch(line dslLine) (string, error) {
+	if len(line.tokens) != 3 || line.tokens[1] != ":" {
+		return "", dslErrorf(line.number, "expected \"match: <strategy>\"")
+	}
+	if err := MatchStrategy(line.tokens[2]).Validate(); err != nil {
+		return "", dslErrorf(line.number, "%s", err.Error())
+	}
+	return line.tokens[2], nil
+}
+
+func checkDSLDuplicateRole(line dslLine, roles []*rawRole, name string) error {
+	if hasRawRole(roles, name) {
+		return dslErrorf(line.number, "role \"%s\" is already defined", name)
+	}
+	return nil
+}
+
+func parseDSLDefaultRoles(line dslLine) ([]string, error) {
+	if len(line.tokens) < 2 || line.tokens[1] != ":" {
+		return nil, dslErrorf(line.number, "expected \"default-roles: <role>, ...\"")
+	}
+	return parseDSLList(line.number, line.tokens[2:], "roles")
+}
+
+func parseDSLSuppression(line dslLine) (*rawSuppression, error) {
+	switch len(line.tokens) {
+	case 2:
+		return &rawSuppression{Rule: line.tokens[1]}, nil
+	case 3:
+		return &rawSuppression{Rule: line.tokens[1], Target: line.tokens[2]}, nil
+	default:
+		return nil, dslErrorf(line.number, "expected \"suppress <rule> [target]\"")
+	}
+}
+
+// Checks that all roles exist either in the schema roles, either in the global roles.
+func checkDSLRoles(line int, names []string, globalRoles []*rawRole, schemaRoles []*rawRole) error {
+	for _, name := range names {
+		if !hasRawRole(globalRoles, name) && !hasRawRole(schemaRoles, name) {
+			return dslErrorf(line, "role \"%s\" isn't defined", name)
+		}
+	}
+	return nil
+}
+
+func (p *dslParser) parseSchema(start dslLine) error {
+	id, err := parseDSLBlockHeader(start)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range p.host.Schemas {
+		if schema.ID == id {
+			return dslErrorf(start.number, "schema \"%s\" is already defined", id)
+		}
+	}
+
+	schema := &rawSchema{ID: id}
+	p.host.Schemas = append(p.host.Schemas, schema)
+
+	return p.parseBlock(start, func(line dslLine) error {
+		switch line.tokens[0] {
+		case "role":
+			role, err := parseDSLRole(line)
+			if err != nil {
+				return err
+			}
+			if err := checkDSLDuplicateRole(line, schema.Roles, role.Name); err != nil {
+				return err
+			}
+			schema.Roles = append(schema.Roles, role)
+		case "default-roles":
+			names, err := parseDSLDefaultRoles(line)
+			if err != nil {
+				return err
+			}
+			schema.DefaultRolesNames = append(schema.DefaultRolesNames, names...)
+			p.checks = append(p.checks, func() error {
+				return checkDSLRoles(line.number, names, p.host.GlobalRoles, schema.Roles)
+			})
+		case "role-merge":
+			merge, err := parseDSLRoleMerge(line)
+			if err != nil {
+				return err
+			}
+			schema.RoleMerge = merge
+		case "resource":
+			names, err := parseDSLList(line.number, line.tokens[1:], "resources")
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if containsString(schema.Resources, name) {
+					return dslErrorf(line.number, "resource \"%s\" is already defined", name)
+				}
+				schema.Resources = append(schema.Resources, name)
+			}
+		case "entity":
+			return p.parseEntity(line, schema)
+		case "deny", "allow":
+			rules, err := p.parseRule(line, schema)
+			if err != nil {
+				return err
+			}
+			schema.ActionGatePolicy = append(schema.ActionGatePolicy, rules...)
+		case "relation":
+			return p.parseRelation(line, schema)
+		case "grant":
+			return p.parseGrant(line, schema)
+		case "tenant":
+			return p.parseTenant(line, schema)
+		case "suppress":
+			suppression, err := parseDSLSuppression(line)
+			if err != nil {
+				return err
+			}
+			schema.Suppress = append(schema.Suppress, suppression)
+		default:
+			return dslErrorf(line.number, "unexpected \"%s\" in the schema block", line.tokens[0])
+		}
+		return nil
+	})
+}
+
+func (p *dslParser) parseEntity(start dslLine, schema *rawSchema) error {
+	name, err := parseDSLBlockHeader(start)
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range schema.Entities {
+		if entity.Name == name {
+			return dslErrorf(start.number, "entity \"%s\" is already defined", name)
+		}
+	}
+
+	entity := &rawEntity{Name: name}
+	schema.Entities = append(schema.Entities, entity)
+
+	return p.parseBlock(start, func(line dslLine) error {
+		tokens := line.tokens
+		if tokens[0] == "match" {
+			if entity.Match != "" {
+				return dslErrorf(line.number, "match strategy of the entity is already defined")
+			}
+			match, err := parseDSLMatch(line)
+			if err != nil {
+				return err
+			}
+			entity.Match = match
+			return nil
+		}
+		if tokens[0] != "action" {
+			return dslErrorf(line.number, "unexpected \"%s\" in the entity block", tokens[0])
+		}
+		if len(tokens) < 2 {
+			return dslErrorf(line.number, "action name is missing")
+		}
+
+		var match string
+		if len(tokens) > 2 && strings.HasPrefix(tokens[2], "(") && strings.HasSuffix(tokens[2], ")") {
+			match = strings.TrimSuffix(strings.TrimPrefix(tokens[2], "("), ")")
+			if err := MatchStrategy(match).Validate(); err != nil {
+				return dslErrorf(line.number, "%s", err.Error())
+			}
+			tokens = append([]string{tokens[0], tokens[1]}, tokens[3:]...)
+		}
+
+		if len(tokens) > 2 && (tokens[2] != "requires" || len(tokens) == 3) {
+			return dslErrorf(line.number, "expected \"action <name> [(<match strategy>)] [requires <permission>, ...]\"")
+		}
+
+		for _, act := range entity.Actions {
+			if act.Name == tokens[1] {
+				return dslErrorf(line.number, "action \"%s\" is already defined", tokens[1])
+			}
+		}
+
+		var permissions *rawPermissions
+		if len(tokens) > 3 {
+			permissions, err = parseDSLPermissions(line.number, tokens[3:])
+		} else {
+			permissions, err = parseDSLPermissions(line.number, nil)
+		}
+		if err != nil {
+			return err
+		}
+
+		entity.Actions = append(entity.Actions, &rawAction{
+			Name:                tokens[1],
+			RequiredPermissions: permissions,
+			Match:               match,
+		})
+
+		return nil
+	})
+}
+
+// Adds check that targets and resource exist in the schema.
+func (p *dslParser) checkDSLTargets(line int, schema *rawSchema, targets []dslTarget, resource string) {
+	p.checks = append(p.checks, func() error {
+		for _, target := range targets {
+			var entity *rawEntity
+			for _, e := range schema.Entities {
+				if e.Name == target.entity {
+					entity = e
+				}
+			}
+			if entity == nil {
+				return dslErrorf(line, "entity \"%s\" isn't defined", target.entity)
+			}
+
+			found := false
+			for _, act := range entity.Actions {
+				found = found || act.Name == target.action
+			}
+			if !found {
+				return dslErrorf(line, "entity \"%s\" doesn't have action \"%s\"", target.entity, target.action)
+			}
+		}
+
+		if !containsString(schema.Resources, resource) {
+			return dslErrorf(line, "resource \"%s\" isn't defined", resource)
+		}
+
+		return nil
+	})
+}
+
+// Parses "deny|allow <entity:action>, ... on <resource> for|unless <role>, ...".
+// Schema is nil for the host-wide rules.
+func (p *dslParser) parseRule(line dslLine, schema *rawSchema) ([]*rawActionGateRules, error) {
+	tokens := line.tokens
+	usage := dslErrorf(line.number, "expected \"%s <entity:action>, ... on <resource> for|unless <role>, ...\"", tokens[0])
+
+	on := indexOfString(tokens, "on")
+	if on < 2 || on+3 >= len(tokens) {
+		return nil, usage
+	}
+
+	targets, err := parseDSLTargets(line.number, tokens[1:on])
+	if err != nil {
+		return nil, err
+	}
+
+	var effect ActionGateEffect
+	switch {
+	case tokens[0] == "deny" && tokens[on+2] == "for":
+		effect = DenyActionGateEffect
+	case tokens[0] == "deny" && tokens[on+2] == "unless":
+		effect = RequireActionGateEffect
+	case tokens[0] == "allow" && tokens[on+2] == "for":
+		effect = AllowActionGateEffect
+	default:
+		return nil, usage
+	}
+
+	roles, err := parseDSLList(line.number, tokens[on+3:], "roles")
+	if err != nil {
+		return nil, err
+	}
+
+	resource := tokens[on+1]
+
+	// Host-wide rules are checked against each schema when host is normalized.
+	if schema != nil {
+		p.checkDSLTargets(line.number, schema, targets, resource)
+		p.checks = append(p.checks, func() error {
+			return checkDSLRoles(line.number, roles, p.host.GlobalRoles, schema.Roles)
+		})
+	}
+
+	entities, actions := groupDSLTargets(targets)
+
+	rules := make([]*rawActionGateRules, len(entities))
+	for i := range entities {
+		rules[i] = &rawActionGateRules{
+			For:    entities[i],
+			Having: roles,
+			Apply:  string(effect),
+			Doing:  actions[i],
+			On:     resource,
+		}
+	}
+
+	return rules, nil
+}
+
+// Parses "relation <type>#<relation>[: <rewrite>, ...]",
+// where rewrite is either "this", either "<relation>", either "<tupleset>-><relation>".
+func (p *dslParser) parseRelation(line dslLine, schema *rawSchema) error {
+	tokens := line.tokens
+	if len(tokens) < 2 || (len(tokens) > 2 && (tokens[2] != ":" || len(tokens) == 3)) {
+		return dslErrorf(line.number, "expected \"relation <type>#<relation>[: <rewrite>, ...]\"")
+	}
+
+	typeName, name, ok := strings.Cut(tokens[1], "#")
+	if !ok || typeName == "" || name == "" {
+		return dslErrorf(line.number, "invalid relation \"%s\", expected \"type#relation\"", tokens[1])
+	}
+
+	var relationType *rawRelationType
+	for _, t := range schema.Relations {
+		if t.Type == typeName {
+			relationType = t
+		}
+	}
+	if relationType == nil {
+		relationType = &rawRelationType{Type: typeName}
+		schema.Relations = append(schema.Relations, relationType)
+	}
+
+	for _, relation := range relationType.Relations {
+		if relation.Name == name {
+			return dslErrorf(line.number, "relation \"%s\" is already defined", tokens[1])
+		}
+	}
+
+	relation := &rawRelation{Name: name}
+	relationType.Relations = append(relationType.Relations, relation)
+
+	if len(tokens) == 2 {
+		return nil
+	}
+
+	items, err := parseDSLList(line.number, tokens[3:], "rewrites")
+	if err != nil {
+		return err
+	}
+
+	rewrites := make([]*rawUsersetRewrite, len(items))
+	var references []string
+
+	for i, item := range items {
+		if item == "this" {
+			rewrites[i] = &rawUsersetRewrite{This: true}
+			continue
+		}
+		if tupleset, computed, ok := strings.Cut(item, "->"); ok {
+			if tupleset == "" || computed == "" {
+				return dslErrorf(line.number, "invalid rewrite \"%s\", expected \"tupleset->relation\"", item)
+			}
+			rewrites[i] = &rawUsersetRewrite{TupleToUserset: &rawTupleToUserset{tupleset, computed}}
+			references = append(references, tupleset)
+			continue
+		}
+		rewrites[i] = &rawUsersetRewrite{ComputedUserset: item}
+		references = append(references, item)
+	}
+
+	if len(rewrites) == 1 {
+		relation.Rewrite = rewrites[0]
+	} else {
+		relation.Rewrite = &rawUsersetRewrite{Union: rewrites}
+	}
+
+	p.checks = append(p.checks, func() error {
+		for _, reference := range references {
+			found := false
+			for _, relation := range relationType.Relations {
+				found = found || relation.Name == reference
+			}
+			if !found {
+				return dslErrorf(line.number, "relation \"%s#%s\" isn't defined", typeName, reference)
+			}
+		}
+		return nil
+	})
+
+	return nil
+}
+
+// Parses "grant <entity:action>, ... on <resource> through <relation>".
+func (p *dslParser) parseGrant(line dslLine, schema *rawSchema) error {
+	tokens := line.tokens
+
+	on := indexOfString(tokens, "on")
+	if on < 2 || len(tokens) != on+4 || tokens[on+2] != "through" {
+		return dslErrorf(line.number, "expected \"grant <entity:action>, ... on <resource> through <relation>\"")
+	}
+
+	targets, err := parseDSLTargets(line.number, tokens[1:on])
+	if err != nil {
+		return err
+	}
+
+	resource, through := tokens[on+1], tokens[on+3]
+
+	p.checkDSLTargets(line.number, schema, targets, resource)
+
+	entities, actions := groupDSLTargets(targets)
+	for i := range entities {
+		schema.RelationGrants = append(schema.RelationGrants, &rawRelationGrant{
+			For:     entities[i],
+			Doing:   actions[i],
+			On:      resource,
+			Through: through,
+		})
+	}
+
+	return nil
+}
+
+func (p *dslParser) parseTenant(start dslLine, schema *rawSchema) error {
+	id, err := parseDSLBlockHeader(start)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range schema.Tenants {
+		if tenant.ID == id {
+			return dslErrorf(start.number, "tenant \"%s\" is already defined", id)
+		}
+	}
+
+	tenant := &rawTenant{ID: id}
+	schema.Tenants = append(schema.Tenants, tenant)
+
+	return p.parseBlock(start, func(line dslLine) error {
+		if line.tokens[0] != "deny" && line.tokens[0] != "allow" {
+			return dslErrorf(line.number, "unexpected \"%s\" in the tenant block", line.tokens[0])
+		}
+		rules, err := p.parseRule(line, schema)
+		if err != nil {
+			return err
+		}
+		tenant.ActionGatePolicy = append(tenant.ActionGatePolicy, rules...)
+		return nil
+	})
+}
+
+type dslFile struct {
+	host *rawHost
+	// True if file consists of a single schema block.
+	isSchema bool
+}
+
+func parseDSL(data []byte) (dslFile, error) {
+	lines, err := tokenizeDSL(data)
+	if err != nil {
+		return dslFile{}, err
+	}
+
+	p := &dslParser{
+		lines: lines,
+		host:  &rawHost{},
+	}
+
+	if err := p.parseHost(); err != nil {
+		return dslFile{}, err
+	}
+
+	for _, check := range p.checks {
+		if err := check(); err != nil {
+			return dslFile{}, err
+		}
+	}
+
+	return dslFile{
+		host:     p.host,
+		isSchema: p.hostStatements == 0 && len(p.host.Schemas) == 1,
+	}, nil
+}
+
+func parseHostDSL(data []byte) (*rawHost, error) {
+	file, err := parseDSL(data)
+	if err != nil {
+		return nil, err
+	}
+	return file.host, nil
+}
+
+func parseSchemaDSL(data []byte) (*rawSchema, error) {
+	file, err := parseDSL(data)
+	if err != nil {
+		return nil, err
+	}
+	if !file.isSchema {
+		return nil, errors.New("schema file must consist of a single schema block")
+	}
+	return file.host.Schemas[0], nil
+}
+
+// Parses host written in the DSL, then normalizes and validates it in the same way as LoadHost.
+func ParseHostDSL(data []byte) (Host, error) {
+	raw, err := parseHostDSL(data)
+	if err != nil {
+		return Host{}, err
+	}
+
+	if err := raw.MergeRoles(); err != nil {
+		return Host{}, err
+	}
+
+	return raw.NormalizeAndValidate()
+}
+
+// Parses schema written in the DSL, then normalizes and validates it in the same way as LoadSchema.
+func ParseSchemaDSL(data []byte) (Schema, error) {
+	raw, err := parseSchemaDSL(data)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return raw.NormalizeAndValidate()
+}
@@ -0,0 +1,301 @@
+package rbac
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+const dslIndent = "    "
+
+type dslWriter struct {
+	buf    bytes.Buffer
+	indent string
+	// Whether blank line must be written before the next group of statements.
+	separate bool
+}
+
+func (w *dslWriter) line(parts ...string) {
+	w.buf.WriteString(w.indent)
+	w.buf.WriteString(strings.Join(parts, " "))
+	w.buf.WriteByte('\n')
+}
+
+// Starts new group of statements, which is separated from the previous one by blank line.
+func (w *dslWriter) group() {
+	if w.separate {
+		w.buf.WriteByte('\n')
+	}
+	w.separate = true
+}
+
+func (w *dslWriter) open(parts ...string) {
+	w.line(append(parts, "{")...)
+	w.indent += dslIndent
+	w.separate = false
+}
+
+func (w *dslWriter) close() {
+	w.indent = strings.TrimSuffix(w.indent, dslIndent)
+	w.line("}")
+	w.separate = true
+}
+
+func formatDSLPermissions(permissions *rawPermissions) string {
+	return strings.Join(permissions.ToBitmask().Names(), ", ")
+}
+
+func formatDSLTargets(entities []string, actions []string) string {
+	var targets []string
+	for _, entity := range entities {
+		for _, act := range actions {
+			targets = append(targets, entity+":"+act)
+		}
+	}
+	return strings.Join(targets, ", ")
+}
+
+func (w *dslWriter) roles(roles []*rawRole) {
+	if len(roles) == 0 {
+		return
+	}
+
+	w.group()
+	for _, role := range roles {
+		parts := []string{"role", role.Name}
+		if role.Merge != "" {
+			parts = append(parts, "("+role.Merge+")")
+		}
+		if permissions := formatDSLPermissions(role.Permissions); permissions != "" {
+			parts[len(parts)-1] += ":"
+			parts = append(parts, permissions)
+		}
+		w.line(parts...)
+	}
+}
+
+func (w *dslWriter) roleMerge(merge string) {
+	if merge == "" {
+		return
+	}
+
+	w.group()
+	w.line("role-merge:", merge)
+}
+
+func (w *dslWriter) defaultRoles(names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	w.group()
+	w.line("default-roles:", strings.Join(names, ", "))
+}
+
+func (w *dslWriter) suppressions(suppressions []*rawSuppression) {
+	if len(suppressions) == 0 {
+		return
+	}
+
+	w.group()
+	for _, suppression := range suppressions {
+		if suppression.Target != "" {
+			w.line("suppress", suppression.Rule, suppression.Target)
+		} else {
+			w.line("suppress", suppression.Rule)
+		}
+	}
+}
+
+func (w *dslWriter) rules(rules []*rawActionGateRules) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	w.group()
+	for _, rule := range rules {
+		targets := formatDSLTargets(rule.For, rule.Doing)
+		roles := strings.Join(rule.Having, ", ")
+
+		switch ActionGateEffect(rule.Apply) {
+		case DenyActionGateEffect:
+			w.line("deny", targets, "on", rule.On, "for", roles)
+		case RequireActionGateEffect:
+			w.line("deny", targets, "on", rule.On, "unless", roles)
+		case AllowActionGateEffect:
+			w.line("allow", targets, "on", rule.On, "for", roles)
+		default:
+			return errors.New("Action Gate Effect \"" + rule.Apply + "\" doesn't exist")
+		}
+	}
+
+	return nil
+}
+
+func formatDSLRewrite(rewrite *rawUsersetRewrite) []string {
+	var items []string
+
+	if rewrite.This {
+		items = append(items, "this")
+	}
+	if rewrite.ComputedUserset != "" {
+		items = append(items, rewrite.ComputedUserset)
+	}
+	if rewrite.TupleToUserset != nil {
+		items = append(items, rewrite.TupleToUserset.Tupleset+"->"+rewrite.TupleToUserset.ComputedUserset)
+	}
+	for _, child := range rewrite.Union {
+		items = append(items, formatDSLRewrite(child)...)
+	}
+
+	return items
+}
+
+func (w *dslWriter) schema(schema *rawSchema) error {
+	w.group()
+	w.open("schema", schema.ID)
+
+	w.defaultRoles(schema.DefaultRolesNames)
+	w.roleMerge(schema.RoleMerge)
+	w.roles(schema.Roles)
+
+	if len(schema.Resources) > 0 {
+		w.group()
+		w.line("resource", strings.Join(schema.Resources, ", "))
+	}
+
+	for _, entity := range schema.Entities {
+		w.group()
+		w.open("entity", entity.Name)
+		if entity.Match != "" {
+			w.line("match:", entity.Match)
+		}
+		for _, act := range entity.Actions {
+			tokens := []string{"action", act.Name}
+			if act.Match != "" {
+				tokens = append(tokens, "("+act.Match+")")
+			}
+			if permissions := formatDSLPermissions(act.RequiredPermissions); permissions != "" {
+				tokens = append(tokens, "requires", permissions)
+			}
+			w.line(tokens...)
+		}
+		w.close()
+	}
+
+	if err := w.rules(schema.ActionGatePolicy); err != nil {
+		return err
+	}
+
+	if len(schema.Relations) > 0 {
+		w.group()
+		for _, t := range schema.Relations {
+			for _, relation := range t.Relations {
+				if relation.Rewrite == nil {
+					w.line("relation", t.Type+"#"+relation.Name)
+				} else {
+					w.line("relation", t.Type+"#"+relation.Name+":", strings.Join(formatDSLRewrite(relation.Rewrite), ", "))
+				}
+			}
+		}
+	}
+
+	if len(schema.RelationGrants) > 0 {
+		w.group()
+		for _, grant := range schema.RelationGrants {
+			w.line("grant", formatDSLTargets(grant.For, grant.Doing), "on", grant.On, "through", grant.Through)
+		}
+	}
+
+	for _, tenant := range schema.Tenants {
+		w.group()
+		w.open("tenant", tenant.ID)
+		if err := w.rules(tenant.ActionGatePolicy); err != nil {
+			return err
+		}
+		w.close()
+	}
+
+	w.suppressions(schema.Suppress)
+
+	w.close()
+
+	return nil
+}
+
+func formatHostDSL(host *rawHost) ([]byte, error) {
+	w := &dslWriter{}
+
+	w.defaultRoles(host.DefaultRolesNames)
+	w.roleMerge(host.RoleMerge)
+	w.roles(host.GlobalRoles)
+	w.suppressions(host.Suppress)
+
+	if host.MissingReferences != "" {
+		w.group()
+		w.line("action-gate-policy-missing:", host.MissingReferences)
+	}
+	if err := w.rules(host.ActionGatePolicy); err != nil {
+		return nil, err
+	}
+
+	if len(host.CrossSchemaGrants) > 0 {
+		w.group()
+		for _, grant := range host.CrossSchemaGrants {
+			w.line(
+				"trust", grant.From, formatDSLTargets(grant.For, grant.Doing),
+				"on", grant.To, strings.Join(grant.On, ", "),
+				"for", strings.Join(grant.Having, ", "),
+			)
+		}
+	}
+
+	if len(host.Include) > 0 {
+		w.group()
+		w.line("include", strings.Join(host.Include, ", "))
+	}
+
+	for _, schema := range host.Schemas {
+		if err := w.schema(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+func formatSchemaDSL(schema *rawSchema) ([]byte, error) {
+	w := &dslWriter{}
+
+	if err := w.schema(schema); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// Formats DSL source in the canonical way: one statement per line, statements grouped by their kind,
+// blocks indented by 4 spaces. Comments are not preserved.
+func FormatDSL(data []byte) ([]byte, error) {
+	file, err := parseDSL(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.isSchema {
+		return formatSchemaDSL(file.host.Schemas[0])
+	}
+
+	return formatHostDSL(file.host)
+}
+
+// Converts DSL source into the JSON configuration.
+// Result is a schema configuration if source consists of a single schema block, otherwise it's a host configuration.
+func DSLToJSON(data []byte) ([]byte, error) {
+	return ConvertConfig(data, DSLConfigFormat, JSONConfigFormat)
+}
+
+// Converts JSON configuration (either host, either schema) into the DSL source.
+func JSONToDSL(data []byte) ([]byte, error) {
+	return ConvertConfig(data, JSONConfigFormat, DSLConfigFormat)
+}
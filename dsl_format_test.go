@@ -0,0 +1,104 @@
+package rbac
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFormatDSL(t *testing.T) {
+	src := `schema svc {
+  deny user:read on cache for support # comment
+resource cache
+	entity user {
+  action read requires   self-read,read
+  action ping
+	}
+  role support: read
+        role admin:   read,delete
+  default-roles: support
+  tenant acme {
+  allow user:read,user:ping on cache for admin
+  }
+}`
+
+	expected := `schema svc {
+    default-roles: support
+
+    role support: read
+    role admin: read, delete
+
+    resource cache
+
+    entity user {
+        action read requires read, self-read
+        action ping
+    }
+
+    deny user:read on cache for support
+
+    tenant acme {
+        allow user:read, user:ping on cache for admin
+    }
+}
+`
+
+	formatted, err := FormatDSL([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if string(formatted) != expected {
+		t.Errorf("Unexpected result:\n%s", formatted)
+	}
+
+	again, err := FormatDSL(formatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(formatted) {
+		t.Errorf("Formatting isn't idempotent:\n%s", again)
+	}
+}
+
+func TestDSLJSONConversion(t *testing.T) {
+	data, err := os.ReadFile("cmd/RBAC.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := JSONToDSL(data)
+	if err != nil {
+		t.Fatalf("Failed to convert JSON into DSL: %v", err)
+	}
+
+	converted, err := DSLToJSON(src)
+	if err != nil {
+		t.Fatalf("Failed to convert DSL into JSON: %v", err)
+	}
+
+	var original, result rawHost
+	if err := json.Unmarshal(data, &original); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(converted, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(original, result) {
+		t.Errorf("Host changed after conversion:\n%s", converted)
+	}
+
+	schemaJSON, err := DSLToJSON([]byte("schema svc {\n    resource cache\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["id"] != "svc" {
+		t.Errorf("Expected schema configuration, got:\n%s", schemaJSON)
+	}
+}
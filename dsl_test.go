@@ -0,0 +1,180 @@
+package rbac
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testDSLHost = `
+# Global roles
+role user: self-read, self-update
+role support: read
+role admin: read, update, delete, self-read
+default-roles: user
+
+schema auth-service {
+    role support: read, self-update
+    default-roles: support
+    resource cache, user
+
+    entity user {
+        action delete requires delete
+        action read requires read
+        action ping
+    }
+
+    deny user:delete on cache unless admin
+    deny user:read on user for support
+    allow user:delete, user:ping on user for admin
+
+    tenant acme {
+        deny user:read on cache for admin
+    }
+
+    suppress RBAC005 user:ping
+}
+`
+
+func TestParseHostDSL(t *testing.T) {
+	host, err := ParseHostDSL([]byte(testDSLHost))
+	if err != nil {
+		t.Fatalf("Failed to parse host: %v", err)
+	}
+
+	if len(host.GlobalRoles) != 3 || len(host.DefaultRoles) != 1 || len(host.Schemas) != 1 {
+		t.Fatalf("Unexpected host: %+v", host)
+	}
+
+	schema := host.Schemas[0]
+
+	support, err := schema.ParseRole("support")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if support.Permissions != ReadPermission|SelfUpdatePermission {
+		t.Errorf("Unexpected support permissions %08b", support.Permissions)
+	}
+
+	if len(schema.ActionGatePolicy.Rules()) != 4 {
+		t.Errorf("Expected 4 rules, got %d", len(schema.ActionGatePolicy.Rules()))
+	}
+	if len(schema.TenantPolicies["acme"].Rules()) != 1 {
+		t.Error("Expected acme tenant to have 1 rule")
+	}
+	if len(schema.Suppressions) != 1 || schema.Suppressions[0].Target != "user:ping" {
+		t.Errorf("Unexpected suppressions %v", schema.Suppressions)
+	}
+
+	user, _ := schema.getEntity("user")
+	cache, _ := schema.getResource("cache")
+	ctx := NewAuthorizationContext(user, "delete", cache)
+
+	rule, ok := schema.ActionGatePolicy.GetRule(&ctx)
+	if !ok || rule.Effect != RequireActionGateEffect {
+		t.Errorf("Expected \"deny ... unless\" to be require rule, got %v", rule)
+	}
+}
+
+func TestParseSchemaDSL(t *testing.T) {
+	src := `
+schema repos {
+    role user: self-read
+    resource repo, team
+    entity user {
+        action read requires read
+    }
+    relation team#member
+    relation repo#parent
+    relation repo#owner
+    relation repo#reader: this, owner, parent->member
+    grant user:read on repo through reader
+}`
+
+	schema, err := ParseSchemaDSL([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if schema.ID != "repos" || schema.Relations == nil {
+		t.Errorf("Unexpected schema: %+v", schema)
+	}
+
+	if _, err := ParseSchemaDSL([]byte(testDSLHost)); err == nil {
+		t.Error("Expected error for host file")
+	}
+}
+
+func TestDSLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+	}{
+		{"unknown statement", "role user: read\nfoo bar", 2},
+		{"unknown permission", "role user: read, fly", 1},
+		{"missing comma", "role user: read self-read", 1},
+		{"trailing comma", "role user: read,", 1},
+		{"duplicate role", "role user\nrole user", 2},
+		{"unclosed block", "\nschema svc {\n    resource cache", 2},
+		{"text after brace", "schema svc {\n} x", 2},
+		{"undefined default role", "role user\n\ndefault-roles: guest", 3},
+		{"rule without roles", "schema svc {\n    deny user:read on cache\n}", 2},
+		{"undefined entity", "role admin\nschema svc {\n    resource cache\n    deny user:read on cache for admin\n}", 4},
+		{"undefined action", "schema svc {\n    role admin\n    resource cache\n    entity user {\n    }\n    allow user:read on cache for admin\n}", 6},
+		{"undefined resource", "schema svc {\n    role admin\n    entity user {\n        action read\n    }\n    allow user:read on db for admin\n}", 6},
+		{"undefined role", "schema svc {\n    resource cache\n    entity user {\n        action read\n    }\n    allow user:read on cache for admin\n}", 6},
+		{"invalid target", "schema svc {\n    allow user on cache for admin\n}", 2},
+		{"invalid relation", "schema svc {\n    relation repo\n}", 2},
+		{"undefined relation", "schema svc {\n    relation repo#reader: owner\n}", 2},
+		{"duplicate action", "schema svc {\n    entity user {\n        action read\n        action read\n    }\n}", 4},
+		{"trust without roles", "trust billing service:call on auth user", 1},
+		{"unknown match strategy", "schema svc {\n    entity user {\n        action read (most) requires read\n    }\n}", 3},
+		{"duplicate entity match", "schema svc {\n    entity user {\n        match: any\n        match: all\n    }\n}", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHostDSL([]byte(tt.src))
+
+			var dslErr *DSLError
+			if !errors.As(err, &dslErr) {
+				t.Fatalf("Expected DSLError, got %v", err)
+			}
+			if dslErr.Line != tt.line {
+				t.Errorf("Expected error at line %d, got %v", tt.line, dslErr)
+			}
+		})
+	}
+}
+
+func TestLoadHostDSL(t *testing.T) {
+	jsonHost, err := LoadHost("cmd/RBAC.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("cmd/RBAC.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := JSONToDSL(data)
+	if err != nil {
+		t.Fatalf("Failed to convert JSON into DSL: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "RBAC"+DSLExtension)
+	if err := os.WriteFile(path, src, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dslHost, err := LoadHost(path)
+	if err != nil {
+		t.Fatalf("Failed to load DSL host: %v", err)
+	}
+
+	if !reflect.DeepEqual(jsonHost, dslHost) {
+		t.Errorf("DSL host differs from the JSON one:\n%+v\n%+v", jsonHost, dslHost)
+	}
+}
@@ -1,17 +1,23 @@
 package rbac
 
-import "errors"
+import (
+	"errors"
+	"sort"
+)
 
 type Entity struct {
 	name    string
 	actions map[Action]Permissions
+	// Strategies of the actions, strategy of the whole entity is stored under the empty action.
+	strategies map[Action]strategy
 }
 
 // Creates a new entity with the specified name.
 func NewEntity(name string) Entity {
 	return Entity{
-		name:    name,
-		actions: make(map[Action]Permissions),
+		name:       name,
+		actions:    make(map[Action]Permissions),
+		strategies: make(map[Action]strategy),
 	}
 }
 
@@ -35,6 +41,9 @@ func (e Entity) NewAction(name string, requiredPermissions Permissions) (Action,
 
 func (e Entity) RemoveAction(act Action) {
 	delete(e.actions, act)
+	if act != "" {
+		delete(e.strategies, act)
+	}
 }
 
 func (e Entity) HasAction(act Action) bool {
@@ -46,3 +55,97 @@ func (e Entity) GetRequiredActionPermissions(act Action) (Permissions, bool) {
 	p, ok := e.actions[act]
 	return p, ok
 }
+
+// Returns names of all entity actions in alphabetical order.
+func (e Entity) Actions() []Action {
+	actions := make([]Action, 0, len(e.actions))
+	for act := range e.actions {
+		actions = append(actions, act)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i] < actions[j]
+	})
+	return actions
+}
+
+// Sets match strategy of all entity actions, which don't have their own strategy.
+// Replaces custom strategy of the entity, if it was set (see SetStrategy).
+func (e Entity) SetMatch(match MatchStrategy) error {
+	if err := match.Validate(); err != nil {
+		return err
+	}
+	e.strategies[""] = strategy{match: match}
+	return nil
+}
+
+// Sets custom strategy of all entity actions, which don't have their own strategy.
+// Pass nil to reset it to the default one.
+func (e Entity) SetStrategy(fn StrategyFunc) {
+	if fn == nil {
+		delete(e.strategies, "")
+		return
+	}
+	e.strategies[""] = strategy{fn: fn}
+}
+
+// Sets match strategy of the action, it takes precedence over the strategy of the entity.
+// Replaces custom strategy of the action, if it was set (see SetActionStrategy).
+func (e Entity) SetActionMatch(act Action, match MatchStrategy) error {
+	if !e.HasAction(act) {
+		return errors.New("\"" + e.name + "\" entity doesn't have \"" + act.String() + "\" action")
+	}
+	if err := match.Validate(); err != nil {
+		return err
+	}
+	e.strategies[act] = strategy{match: match}
+	return nil
+}
+
+// Sets custom strategy of the action, it takes precedence over the strategy of the entity.
+// Pass nil to reset it, so action will use strategy of the entity.
+func (e Entity) SetActionStrategy(act Action, fn StrategyFunc) error {
+	if !e.HasAction(act) {
+		return errors.New("\"" + e.name + "\" entity doesn't have \"" + act.String() + "\" action")
+	}
+	if fn == nil {
+		delete(e.strategies, act)
+		return nil
+	}
+	e.strategies[act] = strategy{fn: fn}
+	return nil
+}
+
+// Returns match strategy of the entity.
+// Returns empty string if it wasn't set or entity uses custom strategy.
+func (e Entity) Match() MatchStrategy {
+	return e.strategies[""].match
+}
+
+// Returns match strategy which was set for the action itself (strategy of the entity isn't taken into account).
+// Returns empty string if it wasn't set or action uses custom strategy.
+func (e Entity) ActionMatch(act Action) MatchStrategy {
+	if act == "" {
+		return ""
+	}
+	return e.strategies[act].match
+}
+
+// Returns match strategy which is used to authorize the action: its own one, then strategy of the entity,
+// AllMatchStrategy if neither of them is set. Returns empty string if action (or entity) uses custom strategy.
+// Note that AllMatchStrategy is reported for actions without strategies even if authorizer has custom AuthzFunc.
+func (e Entity) EffectiveMatch(act Action) MatchStrategy {
+	s, ok := e.strategy(act)
+	if !ok {
+		return AllMatchStrategy
+	}
+	return s.match
+}
+
+// Returns strategy of the action: its own one, or strategy of the entity.
+func (e Entity) strategy(act Action) (strategy, bool) {
+	if s, ok := e.strategies[act]; ok && act != "" {
+		return s, true
+	}
+	s, ok := e.strategies[""]
+	return s, ok
+}
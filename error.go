@@ -1,9 +1,173 @@
 package rbac
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
 
 var (
 	ErrInsufficientPermissions     = errors.New("insufficient permissions to perform this action")
 	ErrEntityDoesNotHaveSuchAction = errors.New("entity doesn't have such action")
 	ErrActionDeniedByAGP           = errors.New("action has been denied by action gate policy")
+	ErrDeniedByInterceptor         = errors.New("action has been denied by interceptor")
+)
+
+var (
+	ErrSchemaNotFound   = errors.New("schema wasn't found")
+	ErrEntityNotFound   = errors.New("entity wasn't found")
+	ErrResourceNotFound = errors.New("resource wasn't found")
+	ErrRoleNotFound     = errors.New("role wasn't found")
+)
+
+// NotFoundError is returned when schema, or entity, resource or role of the schema is referenced by unknown name.
+// It matches the corresponding sentinel error (e.g. ErrRoleNotFound) via errors.Is.
+type NotFoundError struct {
+	// One of ErrSchemaNotFound, ErrEntityNotFound, ErrResourceNotFound or ErrRoleNotFound.
+	Kind error
+	// ID of the schema, which was searched in. Empty if schema itself wasn't found.
+	Schema string
+	Name   string
+}
+
+var notFoundKinds = map[error]string{
+	ErrEntityNotFound:   "entity",
+	ErrResourceNotFound: "resource",
+	ErrRoleNotFound:     "role",
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Kind == ErrSchemaNotFound {
+		return "schema with id \"" + e.Name + "\" wasn't found"
+	}
+	return "schema \"" + e.Schema + "\" doesn't have " + notFoundKinds[e.Kind] + " \"" + e.Name + "\""
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorCode is a stable machine-readable code of the authorization error.
+type ErrorCode string
+
+const (
+	InsufficientPermissionsCode     ErrorCode = "insufficient-permissions"
+	ActionDeniedByAGPCode           ErrorCode = "action-denied-by-agp"
+	EntityDoesNotHaveSuchActionCode ErrorCode = "entity-does-not-have-such-action"
+	CrossSchemaAccessNotGrantedCode ErrorCode = "cross-schema-access-not-granted"
+	DeniedByInterceptorCode         ErrorCode = "denied-by-interceptor"
 )
+
+var errorCodes = map[error]ErrorCode{
+	ErrInsufficientPermissions:     InsufficientPermissionsCode,
+	ErrActionDeniedByAGP:           ActionDeniedByAGPCode,
+	ErrEntityDoesNotHaveSuchAction: EntityDoesNotHaveSuchActionCode,
+	ErrCrossSchemaAccessNotGranted: CrossSchemaAccessNotGrantedCode,
+	ErrDeniedByInterceptor:         DeniedByInterceptorCode,
+}
+
+// AuthorizationError describes why authorization has failed.
+// It wraps one of the sentinel errors (e.g. ErrInsufficientPermissions), so it can be checked via errors.Is.
+type AuthorizationError struct {
+	// Wrapped sentinel error.
+	Err  error
+	Code ErrorCode
+	// Authorization context in the "entity:action:resource" format.
+	Context string
+	// Permissions required by the action.
+	Required Permissions
+	// Merged permissions of all roles.
+	Granted Permissions
+	// Names of the required permissions which roles don't have. Empty if action (or entity) uses "any" or custom
+	// match strategy, since then some of the required permissions are enough, so none of them is missing in particular.
+	Missing []string
+	// Action Gate Policy rule which was found for the context, nil if there are no such rule.
+	Rule *ActionGateRule
+	// Effect of the rule, empty if there are no rule.
+	Effect ActionGateEffect
+}
+
+func (e *AuthorizationError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(e.Err.Error())
+	b.WriteString(" (" + e.Context)
+	switch e.Code {
+	case ActionDeniedByAGPCode:
+		b.WriteString(", " + string(e.Effect) + " rule")
+	case InsufficientPermissionsCode:
+		if len(e.Missing) > 0 {
+			b.WriteString(", missing: " + strings.Join(e.Missing, ", "))
+		}
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+func (e *AuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// Wraps error of the decision into AuthorizationError, if it's one of the authorization sentinel errors.
+// Other errors (e.g. errors of the custom AuthzFunc) are returned as is.
+func newAuthorizationError(ctx *AuthorizationContext, decision *Decision) error {
+	code, ok := errorCodes[decision.Err]
+	if !ok {
+		return decision.Err
+	}
+
+	err := &AuthorizationError{
+		Err:      decision.Err,
+		Code:     code,
+		Context:  ctx.Entity.name + ":" + ctx.Action.String(),
+		Required: decision.Required,
+		Granted:  decision.Granted,
+		Rule:     decision.Rule,
+	}
+	if ctx.Resource != nil {
+		err.Context += ":" + ctx.Resource.name
+	}
+	if decision.Rule != nil {
+		err.Effect = decision.Rule.Effect
+	}
+
+	if ctx.Entity.EffectiveMatch(ctx.Action) == AllMatchStrategy {
+		err.Missing = (decision.Required &^ decision.Granted).Names()
+	}
+
+	return err
+}
+
+// Maps error returned by authorization into HTTP status code and message which is safe to show to the client,
+// since it doesn't reveal any details of the policy:
+//   - nil - 200;
+//   - AuthorizationError caused by denial (insufficient permissions, AGP, cross-schema grants, interceptors) - 403;
+//   - NotFoundError or ErrEntityDoesNotHaveSuchAction (request references unknown names) - 400;
+//   - ErrTenantMissing (context doesn't have tenant or subject) - 400;
+//   - failure of the rule provider (ErrRuleProviderFailed) - 503;
+//   - any other error - 500.
+func HTTPStatus(err error) (status int, message string) {
+	switch {
+	case err == nil:
+		status = http.StatusOK
+	case errors.Is(err, ErrInsufficientPermissions),
+		errors.Is(err, ErrActionDeniedByAGP),
+		errors.Is(err, ErrCrossSchemaAccessNotGranted),
+		errors.Is(err, ErrDeniedByInterceptor):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrEntityDoesNotHaveSuchAction),
+		errors.Is(err, ErrSchemaNotFound),
+		errors.Is(err, ErrEntityNotFound),
+		errors.Is(err, ErrResourceNotFound),
+		errors.Is(err, ErrRoleNotFound),
+		errors.Is(err, ErrTenantMissing):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrRuleProviderFailed):
+		status = http.StatusServiceUnavailable
+	default:
+		status = http.StatusInternalServerError
+	}
+
+	return status, http.StatusText(status)
+}
@@ -1,6 +1,9 @@
 package rbac
 
 import (
+	"errors"
+	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -37,3 +40,151 @@ func TestError(t *testing.T) {
 		t.Error("Legacy ActionDeniedByAGP message incorrect")
 	}
 }
+
+func TestAuthorizationError(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	purgeAction, _ := user.NewAction("purge", DeletePermission|UpdatePermission|SelfDeletePermission)
+	cache := NewResource("cache")
+
+	restricted := NewRole("restricted", ReadPermission)
+	editor := NewRole("editor", UpdatePermission)
+
+	agp := NewActionGatePolicy()
+	readCtx := NewAuthorizationContext(&user, readAction, cache)
+	if err := agp.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{restricted})); err != nil {
+		t.Fatal(err)
+	}
+
+	editAction, _ := user.NewAction("edit", UpdatePermission|DeletePermission)
+	user.SetActionMatch(editAction, AnyMatchStrategy)
+
+	purgeCtx := NewAuthorizationContext(&user, purgeAction, cache)
+	editCtx := NewAuthorizationContext(&user, editAction, cache)
+	unknownCtx := NewAuthorizationContext(&user, "write", cache)
+
+	tests := []struct {
+		name     string
+		ctx      *AuthorizationContext
+		roles    []Role
+		sentinel error
+		expected AuthorizationError
+		message  string
+	}{
+		{
+			name:     "insufficient permissions",
+			ctx:      &purgeCtx,
+			roles:    []Role{editor},
+			sentinel: ErrInsufficientPermissions,
+			expected: AuthorizationError{
+				Code:     InsufficientPermissionsCode,
+				Context:  "user:purge:cache",
+				Required: DeletePermission | UpdatePermission | SelfDeletePermission,
+				Granted:  UpdatePermission,
+				Missing:  []string{"delete", "self-delete"},
+			},
+			message: "insufficient permissions to perform this action (user:purge:cache, missing: delete, self-delete)",
+		},
+		{
+			name:     "insufficient permissions with any matching",
+			ctx:      &editCtx,
+			roles:    []Role{restricted},
+			sentinel: ErrInsufficientPermissions,
+			expected: AuthorizationError{
+				Code:     InsufficientPermissionsCode,
+				Context:  "user:edit:cache",
+				Required: UpdatePermission | DeletePermission,
+				Granted:  ReadPermission,
+			},
+			message: "insufficient permissions to perform this action (user:edit:cache)",
+		},
+		{
+			name:     "denied by AGP",
+			ctx:      &readCtx,
+			roles:    []Role{restricted},
+			sentinel: ErrActionDeniedByAGP,
+			expected: AuthorizationError{
+				Code:     ActionDeniedByAGPCode,
+				Context:  "user:read:cache",
+				Required: ReadPermission,
+				Granted:  ReadPermission,
+				Effect:   DenyActionGateEffect,
+			},
+			message: "action has been denied by action gate policy (user:read:cache, deny rule)",
+		},
+		{
+			name:     "unknown action",
+			ctx:      &unknownCtx,
+			roles:    []Role{editor},
+			sentinel: ErrEntityDoesNotHaveSuchAction,
+			expected: AuthorizationError{
+				Code:    EntityDoesNotHaveSuchActionCode,
+				Context: "user:write:cache",
+			},
+			message: "entity doesn't have such action (user:write:cache)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.ctx, tt.roles, agp)
+
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("Expected %v, got %v", tt.sentinel, err)
+			}
+
+			var authzErr *AuthorizationError
+			if !errors.As(err, &authzErr) {
+				t.Fatalf("Expected AuthorizationError, got %T", err)
+			}
+
+			expected := tt.expected
+			expected.Err = tt.sentinel
+			expected.Rule = authzErr.Rule
+			if !reflect.DeepEqual(*authzErr, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, *authzErr)
+			}
+			if (authzErr.Rule != nil) != (tt.expected.Effect != "") {
+				t.Errorf("Unexpected rule %+v", authzErr.Rule)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("Expected %q, got %q", tt.message, err.Error())
+			}
+		})
+	}
+
+	// Errors of the custom authorization function aren't wrapped.
+	custom := errors.New("custom error")
+	authorizer := NewAuthorizer()
+	authorizer.SetAuthzFunc(func(Permissions, Permissions) error { return custom })
+	if err := authorizer.Authorize(&purgeCtx, nil, nil); err != custom {
+		t.Errorf("Expected custom error as is, got %v", err)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"nil", nil, http.StatusOK, "OK"},
+		{"insufficient permissions", &AuthorizationError{Err: ErrInsufficientPermissions, Context: "user:read:cache"}, http.StatusForbidden, "Forbidden"},
+		{"denied by AGP", ErrActionDeniedByAGP, http.StatusForbidden, "Forbidden"},
+		{"cross-schema", ErrCrossSchemaAccessNotGranted, http.StatusForbidden, "Forbidden"},
+		{"unknown action", ErrEntityDoesNotHaveSuchAction, http.StatusBadRequest, "Bad Request"},
+		{"unknown role", &NotFoundError{Kind: ErrRoleNotFound, Schema: "svc", Name: "ghost"}, http.StatusBadRequest, "Bad Request"},
+		{"missing tenant", ErrTenantMissing, http.StatusBadRequest, "Bad Request"},
+		{"other", errors.New("store is unavailable"), http.StatusInternalServerError, "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := HTTPStatus(tt.err)
+			if status != tt.status || message != tt.message {
+				t.Errorf("Expected %d %q, got %d %q", tt.status, tt.message, status, message)
+			}
+		})
+	}
+}
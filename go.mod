@@ -1,3 +1,5 @@
 module github.com/abaxoth0/SentinelRBAC
 
 go 1.22.2
+
+require gopkg.in/yaml.v3 v3.0.1
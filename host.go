@@ -10,8 +10,28 @@ type Host struct {
 	DefaultRoles []Role
 	GlobalRoles  []Role
 	Schemas      []Schema
+	// Findings of the static analysis which must be ignored in all schemas.
+	Suppressions []Suppression
+	// Grants which allow entities of one schema to perform actions on resources of another one.
+	CrossSchemaGrants []CrossSchemaGrant
+	// Authorizer which is used by Authorize, Decide and cross-schema methods of the host.
+	// If nil, then default (global) authorizer is used.
+	Authorizer *Authorizer
+	// Roles of each schema with their origins, keyed by schema ID. Nil if roles weren't merged.
+	effectiveRoles map[string][]EffectiveRole
+	// Index of the schemas, built when host is loaded. Nil for hosts built manually.
+	index map[string]*schemaIndex
 }
 
+// Returns authorizer of the host, or default authorizer if host doesn't have one.
+func (h *Host) authorizer() *Authorizer {
+	if h.Authorizer != nil {
+		return h.Authorizer
+	}
+	return defaultAuthorizer
+}
+
+// Returns schema with the specified ID. Modifications of the returned schema affect the host.
 func (h *Host) GetSchema(ID string) (*Schema, error) {
 	if h == nil {
 		return nil, errors.New("RBAC schema is not defined")
@@ -21,61 +41,37 @@ func (h *Host) GetSchema(ID string) (*Schema, error) {
 		return nil, errors.New("missing schema id")
 	}
 
-	for _, schema := range h.Schemas {
-		if schema.ID == ID {
-			return &schema, nil
-		}
-	}
-
-	return nil, errors.New("schema with id \"" + ID + "\" wasn't found")
-}
-
-// Merges permissions from schema specific roles with global roles.
-// If any schema have a role with the same name as one of the global roles, then for each that role
-// permissions of the schemas specific roles will overwrite permissions of the global roles.
-// Also adds in schemas all global roles that wasn't explicitly specified for them.
-func (h *rawHost) MergeRoles() {
-	Debug.Log("Merging Host permissions of global and schemas roles...")
-
-	schemas := make([]*rawSchema, len(h.Schemas))
-
-	for i, oldSchema := range h.Schemas {
-		schema := oldSchema
-
-		if oldSchema.Roles == nil || len(oldSchema.Roles) == 0 {
-			schema.Roles = h.GlobalRoles
-			schemas[i] = schema
-			continue
-		}
-
-		roles := []*rawRole{}
-
-		for _, schemaRole := range schema.Roles {
-			for _, globalRole := range h.GlobalRoles {
-				if schemaRole.Name == globalRole.Name {
-					roles = append(roles, schemaRole)
-				} else {
-					roles = append(roles, globalRole)
-				}
-			}
-		}
-
-		schema.Roles = roles
-
-		schemas[i] = schema
+	schema, _, ok := h.lookupSchema(ID)
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrSchemaNotFound, Name: ID}
 	}
 
-	h.Schemas = schemas
-
-	Debug.Log("Merging Host permissions of global and schemas roles: OK")
+	return schema, nil
 }
 
 // Reads RBAC host configuration file from the given path.
+// Format of the file is determined by its extension (see ConfigFormatFromPath).
 // After loading and normalizing, validates this Host and returns an error if any of them were detected.
 // Also merges permissions of the schema specific roles with permissions of the global roles.
 func LoadHost(path string) (Host, error) {
-	host, err := load(path, func(raw *rawHost) {
-		raw.MergeRoles()
+	return LoadHostFormat(path, ConfigFormatFromPath(path))
+}
+
+// Same as LoadHost, but file is parsed in the specified format regardless of its extension.
+func LoadHostFormat(path string, format ConfigFormat) (Host, error) {
+	return LoadHostOptions(path, LoadOptions{Format: format})
+}
+
+// Same as LoadHost, but with the specified options.
+//
+// Before normalization, schemas included by the host are loaded and appended to it,
+// then environment overlay is applied (see LoadOptions.Env).
+func LoadHostOptions(path string, opts LoadOptions) (Host, error) {
+	host, err := load(path, opts.format(path), func(raw *rawHost) error {
+		if err := raw.compose(path, opts); err != nil {
+			return err
+		}
+		return raw.MergeRoles()
 	})
 	if err != nil {
 		return Host{}, err
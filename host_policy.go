@@ -0,0 +1,159 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MissingReferencesPolicy defines what happens when host-wide Action Gate Policy rule
+// references entity, action, resource or role which some schema doesn't have.
+type MissingReferencesPolicy string
+
+const (
+	// Loading fails. Used by default.
+	FailOnMissingReferences MissingReferencesPolicy = "error"
+	// Rule isn't applied to such schema.
+	SkipMissingReferences MissingReferencesPolicy = "skip"
+)
+
+func (p MissingReferencesPolicy) Validate() error {
+	switch p {
+	case FailOnMissingReferences, SkipMissingReferences:
+		return nil
+	default:
+		return errors.New("missing references policy \"" + string(p) + "\" doesn't exist")
+	}
+}
+
+// Returns name of the first entity, action, resource or role referenced by the rule which schema doesn't have.
+// Rule must have exactly one entity and one action (see expandRawRules).
+func (s *rawSchema) missingReference(rule *rawActionGateRules) (string, bool) {
+	var entity *rawEntity
+	for _, e := range s.Entities {
+		if e.Name == rule.For[0] {
+			entity = e
+			break
+		}
+	}
+	if entity == nil {
+		return "entity " + rule.For[0], true
+	}
+
+	found := false
+	for _, act := range entity.Actions {
+		found = found || act.Name == rule.Doing[0]
+	}
+	if !found {
+		return "action " + rule.For[0] + ":" + rule.Doing[0], true
+	}
+
+	if !containsString(s.Resources, rule.On) {
+		return "resource " + rule.On, true
+	}
+
+	for _, role := range rule.Having {
+		if !hasRawRole(s.Roles, role) {
+			return "role " + role, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns Action Gate Policy of the schema combined with the host-wide one.
+// Schema rule takes precedence over the host rule for the same entity:action:resource context,
+// but it can't lift host deny rule: schema (and tenant) rule must be a deny rule for all of its roles as well.
+func (h *rawHost) schemaActionGatePolicy(schema *rawSchema) ([]*rawActionGateRules, error) {
+	if len(h.ActionGatePolicy) == 0 {
+		return schema.ActionGatePolicy, nil
+	}
+
+	missingPolicy := FailOnMissingReferences
+	if h.MissingReferences != "" {
+		missingPolicy = MissingReferencesPolicy(h.MissingReferences)
+		if err := missingPolicy.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid host Action Gate Policy: %s", err.Error())
+		}
+	}
+
+	schemaRules := expandRawRules(schema.ActionGatePolicy)
+
+	rules := append([]*rawActionGateRules(nil), schema.ActionGatePolicy...)
+
+	for _, rule := range h.ActionGatePolicy {
+		if len(rule.For) == 0 || len(rule.Doing) == 0 {
+			return nil, fmt.Errorf("Host Action Gate Policy rule missing entity(-s) or action(-s) for the %s resource", rule.On)
+		}
+	}
+
+	tenantRules := make(map[string][]*rawActionGateRules, len(schema.Tenants))
+	for _, tenant := range schema.Tenants {
+		if tenant != nil {
+			tenantRules[tenant.ID] = expandRawRules(tenant.ActionGatePolicy)
+		}
+	}
+
+	for _, rule := range expandRawRules(h.ActionGatePolicy) {
+		isDeny := ActionGateEffect(rule.Apply) == DenyActionGateEffect
+
+		// Tenant rules take precedence over the schema ones (see TenantPolicy), so they can't lift host deny rule either.
+		for _, tenant := range schema.Tenants {
+			if tenant == nil {
+				continue
+			}
+			if r := findRawRule(tenantRules[tenant.ID], rule); isDeny && r != nil && !deniesAll(r, rule.Having) {
+				return nil, fmt.Errorf(
+					"Rule for %s:%s:%s in the %s tenant of the %s schema conflicts with host deny rule: it must deny all of its roles",
+					rule.For[0], rule.Doing[0], rule.On, tenant.ID, schema.ID,
+				)
+			}
+		}
+
+		if overriding := findRawRule(schemaRules, rule); overriding != nil {
+			if isDeny && !deniesAll(overriding, rule.Having) {
+				return nil, fmt.Errorf(
+					"Rule for %s:%s:%s in the %s schema conflicts with host deny rule: it must deny all of its roles",
+					rule.For[0], rule.Doing[0], rule.On, schema.ID,
+				)
+			}
+			continue
+		}
+
+		if name, missing := schema.missingReference(rule); missing {
+			if missingPolicy == SkipMissingReferences {
+				continue
+			}
+			return nil, fmt.Errorf(
+				"Host Action Gate Policy rule for %s:%s:%s references %s, which doesn't exist in the %s schema",
+				rule.For[0], rule.Doing[0], rule.On, name, schema.ID,
+			)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Returns expanded rule for the same entity:action:resource context as the specified one, nil if there are no such rule.
+func findRawRule(rules []*rawActionGateRules, rule *rawActionGateRules) *rawActionGateRules {
+	for _, r := range rules {
+		if r.For[0] == rule.For[0] && r.Doing[0] == rule.Doing[0] && r.On == rule.On {
+			return r
+		}
+	}
+	return nil
+}
+
+// Reports whether rule is a deny rule for each of the specified roles.
+func deniesAll(rule *rawActionGateRules, roles []string) bool {
+	if ActionGateEffect(rule.Apply) != DenyActionGateEffect {
+		return false
+	}
+	for _, role := range roles {
+		if !containsString(rule.Having, role) {
+			return false
+		}
+	}
+	return true
+}
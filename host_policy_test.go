@@ -0,0 +1,223 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const hostPolicyTestHost = `{
+	"roles": [
+		{"name": "restricted_user", "permissions": {"read": true, "delete": true}},
+		{"name": "admin", "permissions": {"read": true, "delete": true}}
+	],
+	"action-gate-policy": [
+		{"for": ["user"], "doing": ["delete"], "on": "user", "apply": "deny", "having": ["restricted_user"]},
+		{"for": ["user"], "doing": ["read"], "on": "user", "apply": "require", "having": ["admin"]}
+	],
+	"schemas": [
+		{
+			"id": "auth",
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"resources": ["user"],
+			"action-gate-policy": [
+				{"for": ["user"], "doing": ["read"], "on": "user", "apply": "allow", "having": ["restricted_user"]}
+			]
+		},
+		{
+			"id": "profile",
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"resources": ["user"]
+		}
+		SCHEMAS
+	]
+	MISSING
+}`
+
+func loadHostPolicyTestHost(t *testing.T, schemas string, missing string) (Host, error) {
+	t.Helper()
+	config := strings.Replace(hostPolicyTestHost, "SCHEMAS", schemas, 1)
+	config = strings.Replace(config, "MISSING", missing, 1)
+	return loadMergeTestHost(t, config)
+}
+
+func TestHostActionGatePolicy(t *testing.T) {
+	host, err := loadHostPolicyTestHost(t, "", "")
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		act      Action
+		roles    []string
+		expected error
+	}{
+		{"host rule", "profile", "delete", []string{"restricted_user"}, ErrActionDeniedByAGP},
+		{"host rule in schema with own rules", "auth", "delete", []string{"restricted_user"}, ErrActionDeniedByAGP},
+		{"not affected by host rule", "profile", "delete", []string{"admin"}, nil},
+		{"host require rule", "profile", "read", []string{"restricted_user"}, ErrActionDeniedByAGP},
+		{"schema rule overrides host rule", "auth", "read", []string{"restricted_user"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := host.Authorize(test.schema, "user", test.act, "user", test.roles)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestHostActionGatePolicyMissingReferences(t *testing.T) {
+	const billing = `, {
+		"id": "billing",
+		"entities": [{"name": "invoice", "actions": [{"name": "read", "required-permissions": {"read": true}}]}],
+		"resources": ["invoice"]
+	}`
+
+	if _, err := loadHostPolicyTestHost(t, billing, ""); err == nil || !strings.Contains(err.Error(), "entity user, which doesn't exist in the billing schema") {
+		t.Errorf("Expected missing reference error, got %v", err)
+	}
+
+	if _, err := loadHostPolicyTestHost(t, billing, `, "action-gate-policy-missing": "ignore"`); err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("Expected invalid policy error, got %v", err)
+	}
+
+	host, err := loadHostPolicyTestHost(t, billing, `, "action-gate-policy-missing": "skip"`)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	billingSchema, err := host.GetSchema("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(billingSchema.ActionGatePolicy.Rules()) != 0 {
+		t.Errorf("Expected host rules to be skipped for billing schema")
+	}
+	if err := host.Authorize("profile", "user", "delete", "user", []string{"restricted_user"}); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected %v, got %v", ErrActionDeniedByAGP, err)
+	}
+}
+
+func TestHostActionGatePolicyDenyConflict(t *testing.T) {
+	schema := func(apply string, having string) string {
+		return `, {
+			"id": "admin",
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"resources": ["user"],
+			"action-gate-policy": [
+				{"for": ["user"], "doing": ["delete"], "on": "user", "apply": "` + apply + `", "having": [` + having + `]}
+			]
+		}`
+	}
+
+	for _, apply := range []string{"allow", "require"} {
+		if _, err := loadHostPolicyTestHost(t, schema(apply, `"restricted_user"`), ""); err == nil || !strings.Contains(err.Error(), "conflicts with host deny rule") {
+			t.Errorf("Expected %s rule to conflict with host deny rule, got %v", apply, err)
+		}
+	}
+	if _, err := loadHostPolicyTestHost(t, schema("deny", `"admin"`), ""); err == nil || !strings.Contains(err.Error(), "conflicts with host deny rule") {
+		t.Errorf("Expected deny rule without host roles to conflict with host deny rule, got %v", err)
+	}
+
+	// Schema deny rule may extend host one.
+	host, err := loadHostPolicyTestHost(t, schema("deny", `"restricted_user", "admin"`), "")
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+	for _, role := range []string{"restricted_user", "admin"} {
+		if err := host.Authorize("admin", "user", "delete", "user", []string{role}); !errors.Is(err, ErrActionDeniedByAGP) {
+			t.Errorf("Expected %s to be denied, got %v", role, err)
+		}
+	}
+}
+
+func TestHostActionGatePolicyTenantDenyConflict(t *testing.T) {
+	schema := func(apply string, having string) string {
+		return `, {
+			"id": "admin",
+			"entities": [{"name": "user", "actions": [
+				{"name": "read", "required-permissions": {"read": true}},
+				{"name": "delete", "required-permissions": {"delete": true}}
+			]}],
+			"resources": ["user"],
+			"tenants": [{"id": "acme", "action-gate-policy": [
+				{"for": ["user"], "doing": ["delete"], "on": "user", "apply": "` + apply + `", "having": [` + having + `]}
+			]}]
+		}`
+	}
+
+	for _, apply := range []string{"allow", "require"} {
+		if _, err := loadHostPolicyTestHost(t, schema(apply, `"restricted_user"`), ""); err == nil || !strings.Contains(err.Error(), "acme tenant of the admin schema conflicts with host deny rule") {
+			t.Errorf("Expected tenant %s rule to conflict with host deny rule, got %v", apply, err)
+		}
+	}
+
+	host, err := loadHostPolicyTestHost(t, schema("deny", `"restricted_user", "admin"`), "")
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+	admin, err := host.GetSchema("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(admin.Roles) != 2 {
+		t.Fatalf("Expected host roles in the schema, got %v", admin.Roles)
+	}
+	ctx := NewAuthorizationContext(&admin.Entities[0], "delete", &admin.Resources[0])
+	ctx.Tenant = "acme"
+	for _, role := range admin.Roles {
+		if err := Authorize(&ctx, []Role{role}, admin.Policy()); !errors.Is(err, ErrActionDeniedByAGP) {
+			t.Errorf("Expected %s to be denied in the tenant, got %v", role.Name, err)
+		}
+	}
+}
+
+func TestHostActionGatePolicyDSL(t *testing.T) {
+	src := `role admin: read
+
+action-gate-policy-missing: skip
+
+deny user:delete on user for admin
+
+schema auth {
+    resource user
+
+    entity user {
+        action delete requires delete
+    }
+}
+
+schema billing {
+    resource invoice
+}
+`
+	formatted, err := FormatDSL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != src {
+		t.Errorf("Expected:\n%s\nGot:\n%s", src, formatted)
+	}
+
+	host, err := ParseHostDSL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := host.Authorize("auth", "user", "delete", "user", []string{"admin"}); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected %v, got %v", ErrActionDeniedByAGP, err)
+	}
+}
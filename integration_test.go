@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -59,7 +60,7 @@ func TestAuthorizationWithActionGatePolicy(t *testing.T) {
 
 	// Test deny effect
 	err := Authorize(&ctx, []Role{adminRole}, &agp)
-	if err != ErrActionDeniedByAGP {
+	if !errors.Is(err, ErrActionDeniedByAGP) {
 		t.Errorf("Expected ActionDeniedByAGP, got %v", err)
 	}
 
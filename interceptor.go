@@ -0,0 +1,69 @@
+package rbac
+
+// Interceptor runs around each authorization of the Authorizer, e.g. to enforce maintenance lockdown,
+// deny compromised subjects, collect metrics or tracing.
+//
+// Interceptors are called in the following order:
+//   - Before hooks are called in the order in which interceptors were added;
+//   - if Before returns decision, then authorization short-circuits with it:
+//     remaining Before hooks are skipped and action isn't evaluated;
+//   - After hooks are called in the reverse order, only for interceptors which Before hook was called
+//     (including the one which has short-circuited), so they are nested like middlewares.
+//
+// Both hooks are optional.
+type Interceptor struct {
+	// Called before authorization. Return non-nil decision to short-circuit authorization with it.
+	// If returned decision denies action without error, then ErrDeniedByInterceptor is used,
+	// if it doesn't have source, then InterceptorDecisionSource is used.
+	Before func(ctx *AuthorizationContext, roles []Role) *Decision
+	// Called with the final decision.
+	After func(ctx *AuthorizationContext, roles []Role, decision Decision)
+}
+
+// AddInterceptors adds interceptors to the default authorizer, see Interceptor.
+func AddInterceptors(interceptors ...Interceptor) {
+	defaultAuthorizer.AddInterceptors(interceptors...)
+}
+
+// AddInterceptors adds interceptors to this authorizer, they are called after already added ones (see Interceptor).
+// Interceptors are applied to each authorization of the authorizer, including batch ones.
+func (a *Authorizer) AddInterceptors(interceptors ...Interceptor) {
+	a.interceptors = append(a.interceptors, interceptors...)
+}
+
+// RemoveInterceptors removes all interceptors of this authorizer.
+func (a *Authorizer) RemoveInterceptors() {
+	a.interceptors = nil
+}
+
+// Calls Before hooks until one of them returns decision.
+// Returns this decision (nil if there are no such) and number of the called hooks.
+func (a *Authorizer) before(ctx *AuthorizationContext, roles []Role) (*Decision, int) {
+	for i, interceptor := range a.interceptors {
+		if interceptor.Before == nil {
+			continue
+		}
+		if intercepted := interceptor.Before(ctx, roles); intercepted != nil {
+			decision := *intercepted
+			if decision.Allowed {
+				decision.Err = nil
+			} else if decision.Err == nil {
+				decision.Err = ErrDeniedByInterceptor
+			}
+			if decision.Source == "" {
+				decision.Source = InterceptorDecisionSource
+			}
+			return &decision, i + 1
+		}
+	}
+	return nil, len(a.interceptors)
+}
+
+// Calls After hooks of the first n interceptors in the reverse order.
+func (a *Authorizer) after(ctx *AuthorizationContext, roles []Role, decision Decision, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if after := a.interceptors[i].After; after != nil {
+			after(ctx, roles, decision)
+		}
+	}
+}
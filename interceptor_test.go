@@ -0,0 +1,187 @@
+package rbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+	roles := []Role{NewRole("support", ReadPermission)}
+
+	errMaintenance := errors.New("service is under maintenance")
+
+	var calls []string
+	var observed []Decision
+
+	// Records calls and short-circuits with the specified decision if subject matches.
+	interceptor := func(name string, subject string, decision *Decision) Interceptor {
+		return Interceptor{
+			Before: func(ctx *AuthorizationContext, roles []Role) *Decision {
+				calls = append(calls, "before "+name)
+				if ctx.Subject == subject {
+					return decision
+				}
+				return nil
+			},
+			After: func(ctx *AuthorizationContext, roles []Role, decision Decision) {
+				calls = append(calls, "after "+name)
+				if name == "metrics" {
+					observed = append(observed, decision)
+				}
+			},
+		}
+	}
+
+	authorizer := NewAuthorizer()
+	authorizer.AddInterceptors(
+		interceptor("metrics", "", nil),
+		interceptor("lockdown", "maintenance", &Decision{Err: errMaintenance}),
+		Interceptor{After: func(*AuthorizationContext, []Role, Decision) { calls = append(calls, "after observer") }},
+		interceptor("deny-list", "compromised", &Decision{}),
+		interceptor("bypass", "root", &Decision{Allowed: true, Err: ErrInsufficientPermissions}),
+	)
+
+	tests := []struct {
+		name     string
+		act      Action
+		subject  string
+		allowed  bool
+		source   DecisionSource
+		expected error
+		calls    []string
+	}{
+		{
+			"no short-circuit", readAction, "alice", true, PermissionsDecisionSource, nil,
+			[]string{"before metrics", "before lockdown", "before deny-list", "before bypass",
+				"after bypass", "after deny-list", "after observer", "after lockdown", "after metrics"},
+		},
+		{
+			"evaluated denial", deleteAction, "alice", false, PermissionsDecisionSource, ErrInsufficientPermissions,
+			[]string{"before metrics", "before lockdown", "before deny-list", "before bypass",
+				"after bypass", "after deny-list", "after observer", "after lockdown", "after metrics"},
+		},
+		{
+			"short-circuit with custom error", readAction, "maintenance", false, InterceptorDecisionSource, errMaintenance,
+			[]string{"before metrics", "before lockdown", "after lockdown", "after metrics"},
+		},
+		{
+			"short-circuit without error", readAction, "compromised", false, InterceptorDecisionSource, ErrDeniedByInterceptor,
+			[]string{"before metrics", "before lockdown", "before deny-list", "after deny-list", "after observer", "after lockdown", "after metrics"},
+		},
+		{
+			"short-circuit allow", deleteAction, "root", true, InterceptorDecisionSource, nil,
+			[]string{"before metrics", "before lockdown", "before deny-list", "before bypass",
+				"after bypass", "after deny-list", "after observer", "after lockdown", "after metrics"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			observed = nil
+
+			ctx := NewAuthorizationContext(&user, tt.act, cache)
+			ctx.Subject = tt.subject
+
+			decision := authorizer.Decide(&ctx, roles, nil)
+
+			if decision.Allowed != tt.allowed || decision.Source != tt.source {
+				t.Errorf("Expected allowed %v from %s, got %+v", tt.allowed, tt.source, decision)
+			}
+			if !errors.Is(decision.Err, tt.expected) {
+				t.Errorf("Expected error %v, got %v", tt.expected, decision.Err)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("Expected calls %v, got %v", tt.calls, calls)
+			}
+			if len(observed) != 1 || !reflect.DeepEqual(observed[0], decision) {
+				t.Errorf("Expected After hook to observe final decision %+v, got %+v", decision, observed)
+			}
+		})
+	}
+
+	// Short-circuited denial is reported as AuthorizationError with permissions of the context.
+	ctx := NewAuthorizationContext(&user, deleteAction, cache)
+	ctx.Subject = "compromised"
+	var authzErr *AuthorizationError
+	if err := authorizer.Authorize(&ctx, roles, nil); !errors.As(err, &authzErr) || authzErr.Code != DeniedByInterceptorCode || authzErr.Required != DeletePermission {
+		t.Errorf("Expected AuthorizationError with denied-by-interceptor code, got %#v", err)
+	}
+	if status, _ := HTTPStatus(authorizer.Authorize(&ctx, roles, nil)); status != 403 {
+		t.Errorf("Expected status 403, got %d", status)
+	}
+
+	authorizer.RemoveInterceptors()
+	calls = nil
+	if err := authorizer.Authorize(&ctx, roles, nil); !errors.Is(err, ErrInsufficientPermissions) || calls != nil {
+		t.Errorf("Expected interceptors to be removed, got %v and calls %v", err, calls)
+	}
+}
+
+func TestInterceptorsBatch(t *testing.T) {
+	user := NewEntity("user")
+	user.NewAction("read", ReadPermission)
+	user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+
+	authorizer := NewAuthorizer()
+	authorizer.AddInterceptors(Interceptor{
+		Before: func(ctx *AuthorizationContext, roles []Role) *Decision {
+			if ctx.Action == "delete" {
+				return &Decision{}
+			}
+			return nil
+		},
+	})
+
+	roles := []Role{NewRole("admin", ReadPermission|DeletePermission)}
+
+	if allowed := authorizer.AllowedActions(&user, cache, roles, nil); !reflect.DeepEqual(allowed, []Action{"read"}) {
+		t.Errorf("Expected only read to be allowed, got %v", allowed)
+	}
+}
+
+func TestInterceptorsObserveHostDecisions(t *testing.T) {
+	host, err := loadMergeTestHost(t, crossSchemaTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	var observed []Decision
+	AddInterceptors(Interceptor{
+		After: func(ctx *AuthorizationContext, roles []Role, decision Decision) {
+			observed = append(observed, decision)
+		},
+	})
+	defer defaultAuthorizer.RemoveInterceptors()
+
+	decisions := []Decision{
+		host.DecideCrossSchema("billing", "service", "read", "auth-service", "session", []string{"service"}),
+		host.DecideCrossSchema("billing", "service", "read", "auth-service", "user", []string{"service"}),
+		host.DecideCrossSchema("billing", "service", "read", "search", "user", []string{"service"}),
+		host.Decide("billing", "service", "read", "invoice", []string{"auditor"}),
+		host.Decide("billing", "service", "read", "invoice", []string{"guest"}),
+	}
+
+	if !reflect.DeepEqual(observed, decisions) {
+		t.Errorf("Expected After hooks to observe final decisions %+v, got %+v", decisions, observed)
+	}
+	if !errors.Is(decisions[0].Err, ErrCrossSchemaAccessNotGranted) || !errors.Is(decisions[4].Err, ErrRoleNotFound) {
+		t.Errorf("Unexpected decisions %+v", decisions)
+	}
+
+	// Requests with unknown names can be short-circuited as well.
+	AddInterceptors(Interceptor{
+		Before: func(ctx *AuthorizationContext, roles []Role) *Decision {
+			return &Decision{}
+		},
+	})
+	if err := host.Authorize("search", "service", "read", "user", nil); !errors.Is(err, ErrDeniedByInterceptor) {
+		t.Errorf("Expected ErrDeniedByInterceptor, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MergeStrategy defines how schema role is merged with the global role of the same name.
+type MergeStrategy string
+
+const (
+	// Permissions of the schema role replace permissions of the global role. Used by default.
+	OverrideMergeStrategy MergeStrategy = "override"
+	// Role has permissions of both global and schema roles.
+	UnionMergeStrategy MergeStrategy = "union"
+	// Role has only permissions which both global and schema roles have.
+	IntersectionMergeStrategy MergeStrategy = "intersection"
+	// Schema isn't allowed to define role with the same name as the global one.
+	ForbidMergeStrategy MergeStrategy = "forbid"
+)
+
+func (s MergeStrategy) Validate() error {
+	switch s {
+	case OverrideMergeStrategy, UnionMergeStrategy, IntersectionMergeStrategy, ForbidMergeStrategy:
+		return nil
+	default:
+		return errors.New("merge strategy \"" + string(s) + "\" doesn't exist")
+	}
+}
+
+func (s MergeStrategy) merge(global Permissions, schema Permissions) Permissions {
+	switch s {
+	case UnionMergeStrategy:
+		return global | schema
+	case IntersectionMergeStrategy:
+		return global & schema
+	default:
+		return schema
+	}
+}
+
+// Where role (or its permission) came from.
+type RoleOrigin string
+
+const (
+	GlobalRoleOrigin RoleOrigin = "global"
+	SchemaRoleOrigin RoleOrigin = "schema"
+)
+
+// PermissionOrigin shows where single permission of the effective role came from.
+type PermissionOrigin struct {
+	// Single permission bit.
+	Permission Permissions
+	Origins    []RoleOrigin
+}
+
+// EffectiveRole is a role of the schema after merging with the global roles.
+type EffectiveRole struct {
+	Role Role
+	// Empty if role is defined either only globally, either only in the schema, so there was nothing to merge.
+	Strategy MergeStrategy
+	// Permissions of the global role with the same name, nil if there are no such global role.
+	Global *Permissions
+	// Permissions of the schema role, nil if schema doesn't define this role.
+	Schema *Permissions
+	// Origins of each permission of the role.
+	Permissions []PermissionOrigin
+	// Permissions which were granted by the global or the schema role, but were discarded by the merge strategy.
+	Dropped Permissions
+}
+
+func newEffectiveRole(name string, strategy MergeStrategy, global *Permissions, schema *Permissions) EffectiveRole {
+	var globalPermissions, schemaPermissions, permissions Permissions
+
+	if global != nil {
+		globalPermissions = *global
+		permissions = globalPermissions
+	}
+	if schema != nil {
+		schemaPermissions = *schema
+		permissions = schemaPermissions
+	}
+	if global != nil && schema != nil {
+		permissions = strategy.merge(globalPermissions, schemaPermissions)
+	} else {
+		strategy = ""
+	}
+
+	role := EffectiveRole{
+		Role:     NewRole(name, permissions),
+		Strategy: strategy,
+		Global:   global,
+		Schema:   schema,
+		Dropped:  (globalPermissions | schemaPermissions) &^ permissions,
+	}
+
+	for bit := Permissions(1); bit != 0; bit <<= 1 {
+		if permissions&bit == 0 {
+			continue
+		}
+
+		origin := PermissionOrigin{Permission: bit}
+
+		// With override strategy global permissions have no effect on the role.
+		if globalPermissions&bit != 0 && (schema == nil || strategy != OverrideMergeStrategy) {
+			origin.Origins = append(origin.Origins, GlobalRoleOrigin)
+		}
+		if schemaPermissions&bit != 0 {
+			origin.Origins = append(origin.Origins, SchemaRoleOrigin)
+		}
+
+		role.Permissions = append(role.Permissions, origin)
+	}
+
+	return role
+}
+
+// Returns merge strategy for the schema role, most specific strategy wins:
+// strategy of the schema role, then of the global role, then of the schema, then of the host.
+// The only exception is "forbid" strategy of the global role, schema or host: it can't be overridden.
+func (h *rawHost) mergeStrategy(schema *rawSchema, schemaRole *rawRole, globalRole *rawRole) MergeStrategy {
+	for _, s := range []string{globalRole.Merge, schema.RoleMerge, h.RoleMerge} {
+		if MergeStrategy(s) == ForbidMergeStrategy {
+			return ForbidMergeStrategy
+		}
+	}
+	for _, s := range []string{schemaRole.Merge, globalRole.Merge, schema.RoleMerge, h.RoleMerge} {
+		if s != "" {
+			return MergeStrategy(s)
+		}
+	}
+	return OverrideMergeStrategy
+}
+
+// Merges schema roles with global roles according to their merge strategies (see MergeStrategy).
+// Each schema gets all global roles, schema roles with the same name as global ones are merged with them,
+// other schema roles are appended after the global ones.
+func (h *rawHost) MergeRoles() error {
+	Debug.Log("Merging Host permissions of global and schemas roles...")
+
+	if h.RoleMerge != "" {
+		if err := MergeStrategy(h.RoleMerge).Validate(); err != nil {
+			return fmt.Errorf("Invalid host merge strategy: %s", err.Error())
+		}
+	}
+	for _, role := range h.GlobalRoles {
+		if role.Merge == "" {
+			continue
+		}
+		if err := MergeStrategy(role.Merge).Validate(); err != nil {
+			return fmt.Errorf("Invalid merge strategy of the %s global role: %s", role.Name, err.Error())
+		}
+	}
+
+	h.effectiveRoles = make(map[string][]EffectiveRole, len(h.Schemas))
+
+	for _, schema := range h.Schemas {
+		if schema.RoleMerge != "" {
+			if err := MergeStrategy(schema.RoleMerge).Validate(); err != nil {
+				return fmt.Errorf("Invalid merge strategy of the %s schema: %s", schema.ID, err.Error())
+			}
+		}
+
+		schemaRoles := make(map[string]*rawRole, len(schema.Roles))
+		for _, role := range schema.Roles {
+			if role.Merge != "" {
+				if err := MergeStrategy(role.Merge).Validate(); err != nil {
+					return fmt.Errorf("Invalid merge strategy of the %s role in the %s schema: %s", role.Name, schema.ID, err.Error())
+				}
+			}
+			schemaRoles[role.Name] = role
+		}
+
+		roles := make([]*rawRole, 0, len(h.GlobalRoles)+len(schema.Roles))
+		effective := make([]EffectiveRole, 0, cap(roles))
+
+		for _, globalRole := range h.GlobalRoles {
+			global := globalRole.Permissions.ToBitmask()
+
+			schemaRole, ok := schemaRoles[globalRole.Name]
+			if !ok {
+				roles = append(roles, globalRole)
+				effective = append(effective, newEffectiveRole(globalRole.Name, "", &global, nil))
+				continue
+			}
+
+			strategy := h.mergeStrategy(schema, schemaRole, globalRole)
+			if strategy == ForbidMergeStrategy {
+				return fmt.Errorf("Schema %s isn't allowed to override global role %s", schema.ID, globalRole.Name)
+			}
+
+			permissions := schemaRole.Permissions.ToBitmask()
+			role := newEffectiveRole(globalRole.Name, strategy, &global, &permissions)
+
+			roles = append(roles, &rawRole{
+				Name:        globalRole.Name,
+				Permissions: newRawPermissions(role.Role.Permissions),
+			})
+			effective = append(effective, role)
+		}
+
+		for _, schemaRole := range schema.Roles {
+			if hasRawRole(h.GlobalRoles, schemaRole.Name) {
+				continue
+			}
+
+			permissions := schemaRole.Permissions.ToBitmask()
+
+			roles = append(roles, schemaRole)
+			effective = append(effective, newEffectiveRole(schemaRole.Name, "", nil, &permissions))
+		}
+
+		schema.Roles = roles
+		h.effectiveRoles[schema.ID] = effective
+	}
+
+	Debug.Log("Merging Host permissions of global and schemas roles: OK")
+
+	return nil
+}
+
+// Returns roles of the schema with the specified ID, showing where each permission of each role came from.
+//
+// If host wasn't loaded from configuration (so roles weren't merged by it),
+// then all roles are considered to be defined by the schema.
+func (h *Host) EffectiveRoles(schemaID string) ([]EffectiveRole, error) {
+	schema, err := h.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if effective, ok := h.effectiveRoles[schemaID]; ok {
+		result := make([]EffectiveRole, len(effective))
+		copy(result, effective)
+		return result, nil
+	}
+
+	result := make([]EffectiveRole, len(schema.Roles))
+	for i, role := range schema.Roles {
+		permissions := role.Permissions
+		result[i] = newEffectiveRole(role.Name, "", nil, &permissions)
+	}
+
+	return result, nil
+}
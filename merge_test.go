@@ -0,0 +1,228 @@
+package rbac
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mergeTestHost = `{
+	"role-merge": "override",
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true, "self-update": true}},
+		{"name": "support", "permissions": {"read": true, "update": true}},
+		{"name": "admin", "merge": "union", "permissions": {"read": true, "delete": true}}
+	],
+	"schemas": [
+		{
+			"id": "plain"
+		},
+		{
+			"id": "mixed",
+			"role-merge": "intersection",
+			"roles": [
+				{"name": "user", "merge": "override", "permissions": {"self-read": true}},
+				{"name": "support", "permissions": {"read": true, "create": true}},
+				{"name": "admin", "permissions": {"update": true}},
+				{"name": "auditor", "permissions": {"read": true}}
+			]
+		},
+		{
+			"id": "defaults",
+			"roles": [
+				{"name": "support", "permissions": {"create": true}}
+			]
+		}
+	]
+}`
+
+func loadMergeTestHost(t *testing.T, config string) (Host, error) {
+	t.Helper()
+	dir := writeTestFiles(t, map[string]string{"RBAC.json": config})
+	return LoadHost(filepath.Join(dir, "RBAC.json"))
+}
+
+func TestMergeRoles(t *testing.T) {
+	host, err := loadMergeTestHost(t, mergeTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	tests := []struct {
+		schema   string
+		expected []Role
+	}{
+		{"plain", []Role{
+			NewRole("user", SelfReadPermission|SelfUpdatePermission),
+			NewRole("support", ReadPermission|UpdatePermission),
+			NewRole("admin", ReadPermission|DeletePermission),
+		}},
+		{"mixed", []Role{
+			// role strategy takes precedence over the schema one
+			NewRole("user", SelfReadPermission),
+			// schema strategy
+			NewRole("support", ReadPermission),
+			// global role strategy takes precedence over the schema one
+			NewRole("admin", ReadPermission|DeletePermission|UpdatePermission),
+			NewRole("auditor", ReadPermission),
+		}},
+		{"defaults", []Role{
+			NewRole("user", SelfReadPermission|SelfUpdatePermission),
+			// host strategy
+			NewRole("support", CreatePermission),
+			NewRole("admin", ReadPermission|DeletePermission),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, err := host.GetSchema(tt.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(schema.Roles, tt.expected) {
+				t.Errorf("Expected roles %v, got %v", tt.expected, schema.Roles)
+			}
+		})
+	}
+}
+
+func TestMergeRolesErrors(t *testing.T) {
+	tests := map[string]string{
+		"forbid": `{
+			"roles": [{"name": "admin", "merge": "forbid"}],
+			"schemas": [{"id": "svc", "roles": [{"name": "admin", "permissions": {"read": true}}]}]
+		}`,
+		"forbid can't be overridden by schema role": `{
+			"roles": [{"name": "admin", "merge": "forbid", "permissions": {"read": true}}],
+			"schemas": [{"id": "svc", "roles": [{"name": "admin", "merge": "override", "permissions": {"delete": true}}]}]
+		}`,
+		"host forbid can't be overridden by schema role": `{
+			"role-merge": "forbid",
+			"roles": [{"name": "admin", "permissions": {"read": true}}],
+			"schemas": [{"id": "svc", "roles": [{"name": "admin", "merge": "union", "permissions": {"delete": true}}]}]
+		}`,
+		"schema forbid can't be overridden by schema role": `{
+			"roles": [{"name": "admin", "merge": "union", "permissions": {"read": true}}],
+			"schemas": [{"id": "svc", "role-merge": "forbid", "roles": [{"name": "admin", "merge": "override"}]}]
+		}`,
+		"unknown strategy": `{
+			"schemas": [{"id": "svc", "role-merge": "replace"}]
+		}`,
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMergeTestHost(t, config); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	// Forbid only applies to the roles with the same names.
+	config := `{
+		"roles": [{"name": "admin", "merge": "forbid"}],
+		"schemas": [{"id": "svc", "roles": [{"name": "user"}]}]
+	}`
+	if _, err := loadMergeTestHost(t, config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestEffectiveRoles(t *testing.T) {
+	host, err := loadMergeTestHost(t, mergeTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	roles, err := host.EffectiveRoles("mixed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]EffectiveRole{}
+	for _, role := range roles {
+		byName[role.Role.Name] = role
+	}
+
+	admin := byName["admin"]
+	if admin.Strategy != UnionMergeStrategy || admin.Global == nil || admin.Schema == nil {
+		t.Errorf("Unexpected admin role %+v", admin)
+	}
+	expected := []PermissionOrigin{
+		{ReadPermission, []RoleOrigin{GlobalRoleOrigin}},
+		{UpdatePermission, []RoleOrigin{SchemaRoleOrigin}},
+		{DeletePermission, []RoleOrigin{GlobalRoleOrigin}},
+	}
+	if !reflect.DeepEqual(admin.Permissions, expected) {
+		t.Errorf("Expected admin permission origins %v, got %v", expected, admin.Permissions)
+	}
+
+	support := byName["support"]
+	if support.Strategy != IntersectionMergeStrategy || support.Dropped != UpdatePermission|CreatePermission {
+		t.Errorf("Unexpected support role %+v", support)
+	}
+	expected = []PermissionOrigin{{ReadPermission, []RoleOrigin{GlobalRoleOrigin, SchemaRoleOrigin}}}
+	if !reflect.DeepEqual(support.Permissions, expected) {
+		t.Errorf("Expected support permission origins %v, got %v", expected, support.Permissions)
+	}
+
+	user := byName["user"]
+	if user.Dropped != SelfUpdatePermission {
+		t.Errorf("Expected overridden global permissions to be dropped, got %08b", user.Dropped)
+	}
+	expected = []PermissionOrigin{{SelfReadPermission, []RoleOrigin{SchemaRoleOrigin}}}
+	if !reflect.DeepEqual(user.Permissions, expected) {
+		t.Errorf("Expected user permission origins %v, got %v", expected, user.Permissions)
+	}
+
+	auditor := byName["auditor"]
+	if auditor.Strategy != "" || auditor.Global != nil || auditor.Schema == nil {
+		t.Errorf("Unexpected auditor role %+v", auditor)
+	}
+
+	plain, _ := host.EffectiveRoles("plain")
+	for _, role := range plain {
+		if role.Schema != nil || role.Strategy != "" {
+			t.Errorf("Expected only global roles in plain schema, got %+v", role)
+		}
+	}
+
+	if _, err := host.EffectiveRoles("missing"); err == nil {
+		t.Error("Expected error for missing schema")
+	}
+
+	// Host which wasn't loaded from configuration
+	manual := Host{Schemas: []Schema{NewSchema("svc", []Role{NewRole("user", ReadPermission)}, nil, NewActionGatePolicy())}}
+	roles, err = manual.EffectiveRoles("svc")
+	if err != nil || len(roles) != 1 || roles[0].Schema == nil {
+		t.Errorf("Unexpected effective roles %+v, %v", roles, err)
+	}
+}
+
+func TestDSLMergeStrategies(t *testing.T) {
+	src := "role-merge: union\n\nrole admin (forbid): read\nrole user (intersection)\n\nschema svc {\n    role-merge: override\n}\n"
+
+	formatted, err := FormatDSL([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != src {
+		t.Errorf("Unexpected result:\n%s", formatted)
+	}
+
+	json, err := DSLToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"merge": "forbid"`, `"merge": "intersection"`, `"role-merge": "union"`, `"role-merge": "override"`} {
+		if !strings.Contains(string(json), s) {
+			t.Errorf("Expected JSON to contain %s:\n%s", s, json)
+		}
+	}
+
+	if _, err := ParseHostDSL([]byte("role admin (replace): read")); err == nil {
+		t.Error("Expected error for unknown merge strategy")
+	}
+}
@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Loads schema, which entities and resources are defined in code, while roles, default roles
+// and Action Gate Policy (as well as tenants and relations) are defined in the configuration file.
+//
+// Configuration file must not contain entities and resources. References of the AGP rules are validated
+// against the specified entities and resources in the same way as LoadSchema does.
+func LoadPartialSchema(path string, entities []Entity, resources []Resource) (Schema, error) {
+	return LoadPartialSchemaOptions(path, entities, resources, LoadOptions{})
+}
+
+// Same as LoadPartialSchema, but with the specified options (see LoadSchemaOptions).
+func LoadPartialSchemaOptions(path string, entities []Entity, resources []Resource, opts LoadOptions) (Schema, error) {
+	Debug.Log("Loading partial schema '" + path + "'...")
+
+	raw, err := readRaw[*rawSchema](path, opts.format(path))
+	if err != nil {
+		return Schema{}, err
+	}
+
+	if err := raw.compose(path, opts); err != nil {
+		return Schema{}, err
+	}
+
+	schema, err := raw.normalizeAndValidatePartial(entities, resources)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	Debug.Log("Loading partial schema '" + path + "': OK")
+
+	return schema, nil
+}
+
+func (s *rawSchema) normalizeAndValidatePartial(entities []Entity, resources []Resource) (Schema, error) {
+	if len(s.Entities) > 0 || len(s.Resources) > 0 {
+		return Schema{}, fmt.Errorf("Partial schema %s must not define entities and resources in the configuration", s.ID)
+	}
+
+	if err := validateCodeDefinitions(entities, resources); err != nil {
+		return Schema{}, fmt.Errorf("Invalid entities or resources of the %s schema: %s", s.ID, err.Error())
+	}
+
+	schema, err := s.normalize(
+		append([]Entity(nil), entities...),
+		append([]Resource(nil), resources...),
+	)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	if err := ValidateSchema(&schema); err != nil {
+		return Schema{}, err
+	}
+
+	return schema, nil
+}
+
+func validateCodeDefinitions(entities []Entity, resources []Resource) error {
+	entityNames := make(map[string]bool, len(entities))
+	for _, entity := range entities {
+		if entity.name == "" {
+			return errors.New("entity name is missing")
+		}
+		if entityNames[entity.name] {
+			return errors.New("entity \"" + entity.name + "\" is duplicated")
+		}
+		entityNames[entity.name] = true
+	}
+
+	resourceNames := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		if resource.name == "" {
+			return errors.New("resource name is missing")
+		}
+		if resourceNames[resource.name] {
+			return errors.New("resource \"" + resource.name + "\" is duplicated")
+		}
+		resourceNames[resource.name] = true
+	}
+
+	return nil
+}
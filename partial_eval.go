@@ -0,0 +1,110 @@
+package rbac
+
+import "errors"
+
+// Returned by PartialEvaluate if action or its entity uses custom strategy, which can't be evaluated partially.
+var ErrCustomStrategyNotEvaluable = errors.New("custom authorization strategy can't be partially evaluated")
+
+// Options of the partial evaluation.
+type PartialOptions struct {
+	// Resource attribute which holds ID of the resource owner, "owner" by default.
+	OwnerAttribute string
+	// Resource attribute which holds tenant of the resource, "tenant" by default.
+	TenantAttribute string
+	// Conditions on the resource attributes, under which roles grant their permissions, keyed by role name.
+	// Role without condition grants its permissions on all resources.
+	RoleConditions map[string]Condition
+}
+
+// Bits of the permissions which have "self" variant (create, read, update and delete).
+const fullPermissions = CreatePermission | ReadPermission | UpdatePermission | DeletePermission
+
+// Partially evaluates authorization of the action on all resources of the given type: instead of yes or no,
+// returns condition over the resource attributes, which resource must satisfy to be authorized.
+// Condition can be rendered as SQL (see Condition.ToSQL) or Go predicate (see Condition.Predicate).
+//
+// Resource ID of the context is ignored, subject and tenant are used in the following way:
+//   - if required permission isn't granted by roles, but its "self" variant is (e.g. self-read instead of read),
+//     then it's granted only on the resources owned by the subject (see PartialOptions.OwnerAttribute);
+//   - if tenant is specified, then only resources of this tenant are authorized (see PartialOptions.TenantAttribute).
+//
+// Rules of the provider are applied in the same way as by Authorize: if action is denied by rule,
+// then result is False, if it's allowed by rule, then result doesn't depend on the permissions.
+// Match strategy of the action is taken into account, but custom authorization function
+// and relations of the authorizer are not.
+//
+// Returns ErrEntityDoesNotHaveSuchAction if entity doesn't have such action
+// and ErrCustomStrategyNotEvaluable if action uses custom strategy (see StrategyFunc).
+func PartialEvaluate(ctx *AuthorizationContext, roles []Role, provider RuleProvider, opts PartialOptions) (Condition, error) {
+	required, ok := ctx.Entity.GetRequiredActionPermissions(ctx.Action)
+	if !ok {
+		return False(), newAuthorizationError(ctx, &Decision{Err: ErrEntityDoesNotHaveSuchAction})
+	}
+
+	s, _ := ctx.Entity.strategy(ctx.Action)
+	if s.fn != nil {
+		return False(), ErrCustomStrategyNotEvaluable
+	}
+
+	if opts.OwnerAttribute == "" {
+		opts.OwnerAttribute = "owner"
+	}
+	if opts.TenantAttribute == "" {
+		opts.TenantAttribute = "tenant"
+	}
+
+	tenant := True()
+	if ctx.Tenant != "" {
+		tenant = Eq(opts.TenantAttribute, ctx.Tenant)
+	}
+
+	_, bypass, err := applyProviderRules(ctx, roles, provider)
+	if err != nil {
+		return False(), nil
+	}
+	if bypass {
+		return tenant, nil
+	}
+
+	owned := False()
+	if ctx.Subject != "" {
+		owned = Eq(opts.OwnerAttribute, ctx.Subject)
+	}
+
+	// Returns condition under which at least one of the roles grants the permission.
+	grantedBy := func(permission Permissions) Condition {
+		var conditions []Condition
+		for _, role := range roles {
+			if role.Permissions&permission == 0 {
+				continue
+			}
+			if condition, ok := opts.RoleConditions[role.Name]; ok {
+				conditions = append(conditions, condition)
+			} else {
+				conditions = append(conditions, True())
+			}
+		}
+		return Or(conditions...)
+	}
+
+	var conditions []Condition
+
+	for _, p := range permissionNames {
+		if required&p.permission == 0 {
+			continue
+		}
+
+		granted := grantedBy(p.permission)
+		if p.permission&fullPermissions != 0 {
+			granted = Or(granted, And(grantedBy(p.permission<<1), owned))
+		}
+
+		conditions = append(conditions, granted)
+	}
+
+	if s.match == AnyMatchStrategy && len(conditions) > 0 {
+		return And(tenant, Or(conditions...)), nil
+	}
+
+	return And(append([]Condition{tenant}, conditions...)...), nil
+}
@@ -0,0 +1,112 @@
+package rbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPartialEvaluate(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	editAction, _ := user.NewAction("edit", ReadPermission|UpdatePermission)
+	document := NewResource("document")
+
+	reader := NewRole("reader", ReadPermission)
+	author := NewRole("author", SelfReadPermission|SelfUpdatePermission)
+	editor := NewRole("editor", ReadPermission|UpdatePermission)
+	reviewer := NewRole("reviewer", ReadPermission)
+	banned := NewRole("banned", 0)
+
+	agp := NewActionGatePolicy()
+	readCtx := NewAuthorizationContext(&user, readAction, document)
+	if err := agp.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{banned})); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := PartialOptions{
+		OwnerAttribute: "owner_id",
+		RoleConditions: map[string]Condition{"reviewer": Eq("status", "review")},
+	}
+
+	owned := Eq("owner_id", "42")
+
+	tests := []struct {
+		name     string
+		act      Action
+		tenant   string
+		roles    []Role
+		expected Condition
+	}{
+		{"full permission", readAction, "", []Role{reader}, True()},
+		{"self permission", readAction, "", []Role{author}, owned},
+		{"self permissions for each required", editAction, "", []Role{author}, owned},
+		{"mixed", editAction, "", []Role{reader, author}, owned},
+		{"no permissions", editAction, "", []Role{reader}, False()},
+		{"role condition", readAction, "", []Role{reviewer, author}, Or(Eq("status", "review"), owned)},
+		{"role condition and full role", readAction, "", []Role{reviewer, editor}, True()},
+		{"tenant", readAction, "acme", []Role{author}, And(Eq("tenant", "acme"), owned)},
+		{"denied by AGP", readAction, "", []Role{reader, banned}, False()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewAuthorizationContext(&user, tt.act, document)
+			ctx.Subject = "42"
+			ctx.Tenant = tt.tenant
+
+			condition, err := PartialEvaluate(&ctx, tt.roles, agp, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(condition, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, condition)
+			}
+		})
+	}
+
+	// Without subject nothing can be owned.
+	ctx := NewAuthorizationContext(&user, readAction, document)
+	if condition, _ := PartialEvaluate(&ctx, []Role{author}, agp, opts); !condition.IsFalse() {
+		t.Errorf("Expected false without subject, got %v", condition)
+	}
+
+	ctx = NewAuthorizationContext(&user, "delete", document)
+	if _, err := PartialEvaluate(&ctx, []Role{reader}, agp, opts); !errors.Is(err, ErrEntityDoesNotHaveSuchAction) {
+		t.Errorf("Expected %v, got %v", ErrEntityDoesNotHaveSuchAction, err)
+	}
+}
+
+// Residual condition must give the same result as authorization for the resources, which are fully determined by it.
+func TestPartialEvaluateMatchesAuthorize(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	document := NewResource("document")
+
+	allowed := NewRole("allowed", 0)
+	agp := NewActionGatePolicy()
+	ctx := NewAuthorizationContext(&user, readAction, document)
+	if err := agp.AddRule(NewActionGateRule(&ctx, AllowActionGateEffect, []Role{allowed})); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, roles := range [][]Role{
+		{NewRole("reader", ReadPermission)},
+		{NewRole("user", SelfReadPermission)},
+		{allowed},
+		nil,
+	} {
+		condition, err := PartialEvaluate(&ctx, roles, agp, PartialOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		authorized := Authorize(&ctx, roles, agp) == nil
+		if !condition.IsTrue() && authorized {
+			t.Errorf("%v: expected true condition for authorized roles, got %v", roles, condition)
+		}
+		if condition.IsTrue() && !authorized {
+			t.Errorf("%v: expected roles to be authorized for true condition", roles)
+		}
+	}
+}
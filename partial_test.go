@@ -0,0 +1,109 @@
+package rbac
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newPartialTestDefinitions() ([]Entity, []Resource) {
+	user := NewEntity("user")
+	user.NewAction("read", ReadPermission)
+	user.NewAction("delete", DeletePermission)
+
+	return []Entity{user}, []Resource{*NewResource("cache")}
+}
+
+func TestLoadPartialSchema(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"policy.yaml": `
+id: svc
+default-roles: [user]
+roles:
+  - name: user
+    permissions: {self-read: true}
+  - name: moderator
+    permissions: {read: true, delete: true}
+  - name: admin
+    permissions: {read: true, delete: true}
+action-gate-policy:
+  - for: [user]
+    doing: [delete]
+    on: cache
+    apply: require
+    having: [admin]
+`,
+	})
+
+	entities, resources := newPartialTestDefinitions()
+
+	schema, err := LoadPartialSchema(filepath.Join(dir, "policy.yaml"), entities, resources)
+	if err != nil {
+		t.Fatalf("Failed to load partial schema: %v", err)
+	}
+
+	if len(schema.Entities) != 1 || len(schema.Resources) != 1 || len(schema.Roles) != 3 {
+		t.Fatalf("Unexpected schema %+v", schema)
+	}
+
+	moderator, _ := schema.ParseRole("moderator")
+	ctx := NewAuthorizationContext(&entities[0], "delete", &resources[0])
+
+	if err := Authorize(&ctx, []Role{moderator}, schema.Policy()); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected ErrActionDeniedByAGP, got %v", err)
+	}
+
+	ctx.Action = "read"
+	if err := Authorize(&ctx, []Role{moderator}, schema.Policy()); err != nil {
+		t.Errorf("Expected moderator to read, got %v", err)
+	}
+}
+
+func TestLoadPartialSchemaErrors(t *testing.T) {
+	entities, resources := newPartialTestDefinitions()
+
+	tests := []struct {
+		name      string
+		config    string
+		entities  []Entity
+		resources []Resource
+		expected  string
+	}{
+		{
+			"unknown action",
+			`{"id": "svc", "roles": [{"name": "admin"}], "action-gate-policy": [{"for": ["user"], "doing": ["purge"], "on": "cache", "apply": "require", "having": ["admin"]}]}`,
+			entities, resources,
+			"\"purge\" doesn't exist",
+		},
+		{
+			"unknown resource",
+			`{"id": "svc", "roles": [{"name": "admin"}], "action-gate-policy": [{"for": ["user"], "doing": ["read"], "on": "db", "apply": "require", "having": ["admin"]}]}`,
+			entities, resources,
+			"Resource db doesn't exist",
+		},
+		{
+			"entities in config",
+			`{"id": "svc", "entities": [{"name": "user"}]}`,
+			entities, resources,
+			"must not define entities and resources",
+		},
+		{
+			"duplicate entity",
+			`{"id": "svc"}`,
+			[]Entity{entities[0], entities[0]}, resources,
+			"entity \"user\" is duplicated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{"policy.json": tt.config})
+
+			_, err := LoadPartialSchema(filepath.Join(dir, "policy.json"), tt.entities, tt.resources)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package rbac
 
-// Bitmask
-type Permissions = uint16
+import (
+	"errors"
+	"strings"
+)
+
+// Bitmask. It is a defined type rather than an alias of uint16, so uint16 values must be converted explicitly.
+type Permissions uint16
 
 const (
 	CreatePermission Permissions = 1 << iota
@@ -13,3 +18,118 @@ const (
 	DeletePermission
 	SelfDeletePermission
 )
+
+// Names of the permissions as they are used in the configuration files, in the order of their bits.
+var permissionNames = []struct {
+	name       string
+	permission Permissions
+}{
+	{"create", CreatePermission},
+	{"self-create", SelfCreatePermission},
+	{"read", ReadPermission},
+	{"self-read", SelfReadPermission},
+	{"update", UpdatePermission},
+	{"self-update", SelfUpdatePermission},
+	{"delete", DeletePermission},
+	{"self-delete", SelfDeletePermission},
+}
+
+// Short form letters of the permissions: uppercase letter means permission, lowercase - its "self" variant.
+var permissionLetters = map[byte]Permissions{
+	'C': CreatePermission,
+	'c': SelfCreatePermission,
+	'R': ReadPermission,
+	'r': SelfReadPermission,
+	'U': UpdatePermission,
+	'u': SelfUpdatePermission,
+	'D': DeletePermission,
+	'd': SelfDeletePermission,
+}
+
+// Returns names of the permissions in the order of their bits.
+func (p Permissions) Names() []string {
+	var names []string
+	for _, n := range permissionNames {
+		if p&n.permission != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// Returns permissions names joined by "|", e.g. "create|read|self-update", or "none" if there are no permissions.
+func (p Permissions) String() string {
+	if p == 0 {
+		return "none"
+	}
+	return strings.Join(p.Names(), "|")
+}
+
+func (p Permissions) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Permissions) UnmarshalText(text []byte) error {
+	permissions, err := ParsePermissions(string(text))
+	if err != nil {
+		return err
+	}
+	*p = permissions
+	return nil
+}
+
+func parsePermissionName(name string) (Permissions, bool) {
+	for _, n := range permissionNames {
+		if n.name == name {
+			return n.permission, true
+		}
+	}
+	return 0, false
+}
+
+// Parses permissions in one of the following formats:
+//   - names joined by "|", e.g. "create|read|self-update" (see Permissions.String);
+//   - short form, where each letter of "CRUD" means permission if it's uppercase and its "self" variant
+//     if it's lowercase, while '-' means nothing, e.g. "CRUD" or "cR-u";
+//   - "none" or empty string for no permissions.
+func ParsePermissions(s string) (Permissions, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "" || s == "none" {
+		return 0, nil
+	}
+
+	if permission, ok := parsePermissionName(s); ok {
+		return permission, nil
+	}
+
+	if !strings.Contains(s, "|") && isShortPermissions(s) {
+		var permissions Permissions
+		for i := 0; i < len(s); i++ {
+			permissions |= permissionLetters[s[i]]
+		}
+		return permissions, nil
+	}
+
+	var permissions Permissions
+
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+		permission, ok := parsePermissionName(name)
+		if !ok {
+			return 0, errors.New("unknown permission \"" + name + "\"")
+		}
+		permissions |= permission
+	}
+
+	return permissions, nil
+}
+
+func isShortPermissions(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if _, ok := permissionLetters[s[i]]; !ok && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,11 @@
 package rbac
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -39,3 +44,131 @@ func TestPermissions(t *testing.T) {
 		t.Error("Permitted should satisfy required")
 	}
 }
+
+func TestPermissionsString(t *testing.T) {
+	tests := []struct {
+		permissions Permissions
+		expected    string
+	}{
+		{0, "none"},
+		{ReadPermission, "read"},
+		{CreatePermission | ReadPermission | SelfUpdatePermission, "create|read|self-update"},
+		{SelfDeletePermission | CreatePermission, "create|self-delete"},
+	}
+
+	for _, tt := range tests {
+		if s := tt.permissions.String(); s != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, s)
+		}
+		if s := fmt.Sprint(tt.permissions); s != tt.expected {
+			t.Errorf("Expected %q to be printed, got %q", tt.expected, s)
+		}
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Permissions
+		err      bool
+	}{
+		{"", 0, false},
+		{"none", 0, false},
+		{"read", ReadPermission, false},
+		{"create|read|self-update", CreatePermission | ReadPermission | SelfUpdatePermission, false},
+		{" read | self-read ", ReadPermission | SelfReadPermission, false},
+		{"CRUD", CreatePermission | ReadPermission | UpdatePermission | DeletePermission, false},
+		{"crud", SelfCreatePermission | SelfReadPermission | SelfUpdatePermission | SelfDeletePermission, false},
+		{"cR-u", SelfCreatePermission | ReadPermission | SelfUpdatePermission, false},
+		{"----", 0, false},
+		{"read|fly", 0, true},
+		{"read|", 0, true},
+		{"CRUDX", 0, true},
+		{"R|U", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			permissions, err := ParsePermissions(tt.input)
+			if (err != nil) != tt.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if permissions != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, permissions)
+			}
+		})
+	}
+
+	for p := Permissions(0); p <= 0xFF; p++ {
+		parsed, err := ParsePermissions(p.String())
+		if err != nil || parsed != p {
+			t.Errorf("Failed to parse %q back: %v, %v", p.String(), parsed, err)
+		}
+	}
+}
+
+func TestPermissionsText(t *testing.T) {
+	var value struct {
+		Permissions Permissions `json:"permissions"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"permissions": "cR-u"}`), &value); err != nil {
+		t.Fatal(err)
+	}
+	if value.Permissions != SelfCreatePermission|ReadPermission|SelfUpdatePermission {
+		t.Errorf("Unexpected permissions %v", value.Permissions)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"permissions":"self-create|read|self-update"}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"permissions": "fly"}`), &value); err == nil {
+		t.Error("Expected error for unknown permission")
+	}
+
+	// Values of uint16 type (e.g. stored in database) are converted explicitly.
+	stored := uint16(ReadPermission | SelfUpdatePermission)
+	if p := Permissions(stored); p.String() != "read|self-update" || uint16(p) != stored {
+		t.Errorf("Unexpected conversion of %d: %v", stored, p)
+	}
+}
+
+func TestLoadSchemaStringPermissions(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"schema.json": `{
+		"id": "svc",
+		"roles": [
+			{"name": "user", "permissions": "self-read|self-update"},
+			{"name": "admin", "permissions": "CRUD"},
+			{"name": "support", "permissions": {"read": true}}
+		],
+		"entities": [{"name": "user", "actions": [{"name": "read", "required-permissions": "read"}]}],
+		"resources": ["cache"]
+	}`})
+
+	schema, err := LoadSchema(filepath.Join(dir, "schema.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	expected := []Role{
+		NewRole("user", SelfReadPermission|SelfUpdatePermission),
+		NewRole("admin", CreatePermission|ReadPermission|UpdatePermission|DeletePermission),
+		NewRole("support", ReadPermission),
+	}
+	if !reflect.DeepEqual(schema.Roles, expected) {
+		t.Errorf("Expected %v, got %v", expected, schema.Roles)
+	}
+	if required, _ := schema.Entities[0].GetRequiredActionPermissions("read"); required != ReadPermission {
+		t.Errorf("Expected %v, got %v", ReadPermission, required)
+	}
+
+	dir = writeTestFiles(t, map[string]string{"schema.json": `{"id": "svc", "roles": [{"name": "user", "permissions": "read|fly"}]}`})
+	if _, err := LoadSchema(filepath.Join(dir, "schema.json")); err == nil || !strings.Contains(err.Error(), `unknown permission "fly"`) {
+		t.Errorf("Expected unknown permission error, got %v", err)
+	}
+}
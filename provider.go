@@ -0,0 +1,131 @@
+package rbac
+
+// RuleProviderFunc is a RuleProvider backed by a function, e.g. by lookup in the deny-list which is fetched at runtime.
+type RuleProviderFunc func(ctx *AuthorizationContext) (*ActionGateRule, bool)
+
+func (f RuleProviderFunc) GetRule(ctx *AuthorizationContext) (*ActionGateRule, bool) {
+	return f(ctx)
+}
+
+// MultiRuleProvider is a RuleProvider which may have several rules for the same context (see AllProviders).
+// Authorization applies all of them: if any rule denies action, then it's denied,
+// otherwise if any rule allows action, then it's allowed, otherwise it's authorized by permissions.
+type MultiRuleProvider interface {
+	RuleProvider
+	// Returns all rules for the context, nil if there are no such.
+	GetRules(ctx *AuthorizationContext) []*ActionGateRule
+}
+
+// Returns all rules of the provider for the context.
+func providerRules(provider RuleProvider, ctx *AuthorizationContext) []*ActionGateRule {
+	if provider == nil {
+		return nil
+	}
+	if multi, ok := provider.(MultiRuleProvider); ok {
+		return multi.GetRules(ctx)
+	}
+	if rule, ok := provider.GetRule(ctx); ok {
+		return []*ActionGateRule{rule}
+	}
+	return nil
+}
+
+// Applies all rules of the provider for the context, denial overrides everything else.
+// Returns rule which determined the result (or the first found rule, if none of them affected it),
+// true if default authorization must be skipped and error if action is denied.
+func applyProviderRules(ctx *AuthorizationContext, roles []Role, provider RuleProvider) (*ActionGateRule, bool, error) {
+	rules := providerRules(provider, ctx)
+	if len(rules) == 0 {
+		return nil, false, nil
+	}
+
+	var allowedBy *ActionGateRule
+
+	for _, rule := range rules {
+		bypass, err := rule.Apply(ctx.Action, roles)
+		if err != nil {
+			return rule, false, err
+		}
+		if bypass && allowedBy == nil {
+			allowedBy = rule
+		}
+	}
+
+	if allowedBy != nil {
+		return allowedBy, true, nil
+	}
+
+	return rules[0], false, nil
+}
+
+type chainProvider []RuleProvider
+
+// Combines providers, so rule for the context is looked up in each of them in the specified order,
+// the first found rule is used (rules of the remaining providers are ignored). Nil providers are skipped.
+func ChainProviders(providers ...RuleProvider) MultiRuleProvider {
+	return chainProvider(nonNilProviders(providers))
+}
+
+func (c chainProvider) GetRule(ctx *AuthorizationContext) (*ActionGateRule, bool) {
+	for _, provider := range c {
+		if rule, ok := provider.GetRule(ctx); ok {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+func (c chainProvider) GetRules(ctx *AuthorizationContext) []*ActionGateRule {
+	for _, provider := range c {
+		if rules := providerRules(provider, ctx); len(rules) > 0 {
+			return rules
+		}
+	}
+	return nil
+}
+
+type allProvider []RuleProvider
+
+// Combines providers, so rules of all of them are applied (deny-overrides):
+// action is denied if it's denied by rule of any provider (including unsatisfied "require" rules),
+// otherwise it's allowed if any rule allows it, otherwise it's authorized by permissions.
+// Nil providers are skipped.
+//
+// Since GetRule can return only one rule, it returns the most restrictive one: "deny", then "require", then "allow".
+func AllProviders(providers ...RuleProvider) MultiRuleProvider {
+	return allProvider(nonNilProviders(providers))
+}
+
+var effectRestrictiveness = map[ActionGateEffect]int{
+	DenyActionGateEffect:    2,
+	RequireActionGateEffect: 1,
+	AllowActionGateEffect:   0,
+}
+
+func (a allProvider) GetRule(ctx *AuthorizationContext) (*ActionGateRule, bool) {
+	var result *ActionGateRule
+	for _, rule := range a.GetRules(ctx) {
+		if result == nil || effectRestrictiveness[rule.Effect] > effectRestrictiveness[result.Effect] {
+			result = rule
+		}
+	}
+	return result, result != nil
+}
+
+func (a allProvider) GetRules(ctx *AuthorizationContext) []*ActionGateRule {
+	var rules []*ActionGateRule
+	for _, provider := range a {
+		rules = append(rules, providerRules(provider, ctx)...)
+	}
+	return rules
+}
+
+func nonNilProviders(providers []RuleProvider) []RuleProvider {
+	result := make([]RuleProvider, 0, len(providers))
+	for _, provider := range providers {
+		if provider != nil {
+			result = append(result, provider)
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComposableProviders(t *testing.T) {
+	user := NewEntity("user")
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	readAction, _ := user.NewAction("read", ReadPermission)
+	cache := NewResource("cache")
+
+	admin := NewRole("admin", DeletePermission)
+	support := NewRole("support", 0)
+	compromised := NewRole("compromised", ReadPermission|DeletePermission)
+
+	deleteCtx := NewAuthorizationContext(&user, deleteAction, cache)
+	readCtx := NewAuthorizationContext(&user, readAction, cache)
+
+	schemaPolicy := NewActionGatePolicy()
+	schemaPolicy.AddRule(NewActionGateRule(&deleteCtx, RequireActionGateEffect, []Role{admin, compromised}))
+	schemaPolicy.AddRule(NewActionGateRule(&readCtx, AllowActionGateEffect, []Role{support}))
+
+	tenantPolicy := NewActionGatePolicy()
+	tenantPolicy.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{support}))
+
+	// Emergency deny-list, which is fetched at runtime.
+	denied := map[string]bool{"compromised": true}
+	denyList := RuleProviderFunc(func(ctx *AuthorizationContext) (*ActionGateRule, bool) {
+		var roles []Role
+		for name := range denied {
+			roles = append(roles, NewRole(name, 0))
+		}
+		return NewActionGateRule(ctx, DenyActionGateEffect, roles), true
+	})
+
+	tests := []struct {
+		name     string
+		provider RuleProvider
+		ctx      AuthorizationContext
+		roles    []Role
+		expected error
+		effect   ActionGateEffect
+	}{
+		{"function provider", denyList, readCtx, []Role{compromised}, ErrActionDeniedByAGP, DenyActionGateEffect},
+		{"chain uses first found rule", ChainProviders(nil, tenantPolicy, schemaPolicy), readCtx, []Role{support}, ErrActionDeniedByAGP, DenyActionGateEffect},
+		{"chain falls through", ChainProviders(tenantPolicy, schemaPolicy), deleteCtx, []Role{admin}, nil, RequireActionGateEffect},
+		{"chain ignores remaining providers", ChainProviders(schemaPolicy, denyList), deleteCtx, []Role{compromised}, nil, RequireActionGateEffect},
+		{"all applies every provider", AllProviders(schemaPolicy, denyList), deleteCtx, []Role{compromised}, ErrActionDeniedByAGP, DenyActionGateEffect},
+		{"deny overrides allow", AllProviders(schemaPolicy, tenantPolicy), readCtx, []Role{support}, ErrActionDeniedByAGP, DenyActionGateEffect},
+		{"allow without denial", AllProviders(schemaPolicy, denyList), readCtx, []Role{support}, nil, AllowActionGateEffect},
+		{"unsatisfied require denies", AllProviders(denyList, schemaPolicy), deleteCtx, []Role{support}, ErrActionDeniedByAGP, RequireActionGateEffect},
+		{"not affecting rules", AllProviders(denyList, schemaPolicy), deleteCtx, []Role{admin}, nil, DenyActionGateEffect},
+		{"nested", ChainProviders(tenantPolicy, AllProviders(schemaPolicy, denyList)), deleteCtx, []Role{compromised}, ErrActionDeniedByAGP, DenyActionGateEffect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Decide(&tt.ctx, tt.roles, tt.provider)
+
+			if !errors.Is(decision.Err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, decision.Err)
+			}
+			if decision.Rule == nil || decision.Rule.Effect != tt.effect {
+				t.Errorf("Expected %s rule in the decision, got %+v", tt.effect, decision.Rule)
+			}
+		})
+	}
+
+	if rule, ok := AllProviders(schemaPolicy, tenantPolicy).GetRule(&readCtx); !ok || rule.Effect != DenyActionGateEffect {
+		t.Errorf("Expected the most restrictive rule, got %+v", rule)
+	}
+	if _, ok := ChainProviders().GetRule(&readCtx); ok {
+		t.Error("Expected empty chain to have no rules")
+	}
+	if err := Authorize(&readCtx, []Role{compromised}, AllProviders()); err != nil {
+		t.Errorf("Expected empty providers to authorize by permissions, got %v", err)
+	}
+}
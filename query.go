@@ -0,0 +1,166 @@
+package rbac
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// PermittedAction is a single authorization context (in the string form) which is permitted for some roles.
+type PermittedAction struct {
+	Entity   string
+	Action   Action
+	Resource string
+}
+
+func (p PermittedAction) String() string {
+	return p.Entity + ":" + p.Action.String() + ":" + p.Resource
+}
+
+// Returns every minimal combination of schema roles which passes authorization
+// of the given context (including effects of the schema Action Gate Policy).
+//
+// Combination is minimal if none of its subsets passes authorization,
+// so if some role can perform an action on its own, then it will be returned as a single role combination.
+// Combinations are sorted by size, roles in each combination are in the same order as in the schema.
+// Only the schema is taken into account: global interceptors, authorization function and relations are not applied.
+func (schema *Schema) WhoCan(entityName string, act Action, resourceName string) ([][]Role, error) {
+	entity, ok := schema.getEntity(entityName)
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrEntityNotFound, Schema: schema.ID, Name: entityName}
+	}
+	resource, ok := schema.getResource(resourceName)
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrResourceNotFound, Schema: schema.ID, Name: resourceName}
+	}
+	required, ok := entity.GetRequiredActionPermissions(act)
+	if !ok {
+		return nil, ErrEntityDoesNotHaveSuchAction
+	}
+
+	if len(schema.Roles) > 64 {
+		return nil, errors.New("schema \"" + schema.ID + "\" has too many roles to find their combinations")
+	}
+
+	ctx := NewAuthorizationContext(entity, act, resource)
+	provider := schema.Policy()
+	authorizer := NewAuthorizer()
+
+	// Each role in minimal combination must either grant a permission which other roles don't,
+	// either be required by the Action Gate Policy, so there is no need to check bigger combinations.
+	maxSize := bits.OnesCount16(uint16(required)) + 1
+	// Custom strategy may require any combination of roles.
+	if s, _ := entity.strategy(act); s.fn != nil || maxSize > len(schema.Roles) {
+		maxSize = len(schema.Roles)
+	}
+
+	var result [][]Role
+	var found []uint64
+
+	for size := 1; size <= maxSize; size++ {
+		forEachCombination(len(schema.Roles), size, func(indices []int) {
+			var mask uint64
+			for _, i := range indices {
+				mask |= 1 << i
+			}
+			for _, f := range found {
+				if f&mask == f {
+					// Superset of already found combination, so it isn't minimal.
+					return
+				}
+			}
+
+			roles := make([]Role, len(indices))
+			for i, idx := range indices {
+				roles[i] = schema.Roles[idx]
+			}
+
+			if authorizer.Authorize(&ctx, roles, provider) == nil {
+				found = append(found, mask)
+				result = append(result, roles)
+			}
+		})
+	}
+
+	return result, nil
+}
+
+// Calls fn for each combination of k indices from [0, n).
+func forEachCombination(n int, k int, fn func(indices []int)) {
+	if k > n || k <= 0 {
+		return
+	}
+
+	indices := make([]int, k)
+	for i := range indices {
+		indices[i] = i
+	}
+
+	for {
+		fn(indices)
+
+		i := k - 1
+		for i >= 0 && indices[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+
+		indices[i]++
+		for j := i + 1; j < k; j++ {
+			indices[j] = indices[j-1] + 1
+		}
+	}
+}
+
+// Returns every entity:action:resource context of this schema which is permitted for the given roles
+// (including effects of the schema Action Gate Policy). Same as WhoCan, only the schema is taken into account.
+func (schema *Schema) WhatCan(roles []Role) []PermittedAction {
+	result := []PermittedAction{}
+	provider := schema.Policy()
+	authorizer := NewAuthorizer()
+
+	for i := range schema.Entities {
+		entity := &schema.Entities[i]
+
+		for _, act := range entity.Actions() {
+			for j := range schema.Resources {
+				ctx := NewAuthorizationContext(entity, act, &schema.Resources[j])
+
+				if authorizer.Authorize(&ctx, roles, provider) == nil {
+					result = append(result, PermittedAction{
+						Entity:   entity.name,
+						Action:   act,
+						Resource: schema.Resources[j].name,
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// Same as Schema.WhoCan, but for the schema with the specified ID.
+func (h *Host) WhoCan(schemaID string, entityName string, act Action, resourceName string) ([][]Role, error) {
+	schema, err := h.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	return schema.WhoCan(entityName, act, resourceName)
+}
+
+// Same as Schema.WhatCan, but for the schema with the specified ID and roles specified by their names.
+func (h *Host) WhatCan(schemaID string, roleNames []string) ([]PermittedAction, error) {
+	schema, err := h.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := rolesByNames(buildRoleMap(schema.Roles), roleNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.WhatCan(roles), nil
+}
@@ -0,0 +1,178 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func newQueryTestSchema(t *testing.T) Schema {
+	t.Helper()
+
+	user := NewEntity("user")
+	user.NewAction("delete", DeletePermission)
+	user.NewAction("read", ReadPermission)
+	user.NewAction("purge", DeletePermission|UpdatePermission)
+
+	cache := NewResource("cache")
+
+	roles := []Role{
+		NewRole("user", SelfReadPermission),
+		NewRole("reader", ReadPermission),
+		NewRole("deleter", DeletePermission),
+		NewRole("updater", UpdatePermission),
+		NewRole("admin", ReadPermission|UpdatePermission|DeletePermission),
+		NewRole("restricted", ReadPermission),
+	}
+
+	schema := NewSchema("svc", roles, nil, NewActionGatePolicy())
+	schema.Entities = []Entity{user}
+	schema.Resources = []Resource{*cache}
+
+	readCtx := NewAuthorizationContext(&user, "read", cache)
+	if err := schema.ActionGatePolicy.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{roles[5]})); err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func combinationsToStrings(combinations [][]Role) []string {
+	result := make([]string, len(combinations))
+	for i, roles := range combinations {
+		for j, name := range GetRolesNames(roles) {
+			if j > 0 {
+				result[i] += "+"
+			}
+			result[i] += name
+		}
+	}
+	return result
+}
+
+func TestWhoCan(t *testing.T) {
+	schema := newQueryTestSchema(t)
+
+	tests := []struct {
+		name     string
+		action   Action
+		expected []string
+	}{
+		{"single roles", "delete", []string{"deleter", "admin"}},
+		{"deny rule excludes role", "read", []string{"reader", "admin"}},
+		{"minimal combinations", "purge", []string{"admin", "deleter+updater"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			combinations, err := schema.WhoCan("user", tt.action, "cache")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := combinationsToStrings(combinations)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+
+	if _, err := schema.WhoCan("bot", "delete", "cache"); err == nil {
+		t.Error("Expected error for unknown entity")
+	}
+	if _, err := schema.WhoCan("user", "delete", "storage"); err == nil {
+		t.Error("Expected error for unknown resource")
+	}
+	if _, err := schema.WhoCan("user", "update", "cache"); !errors.Is(err, ErrEntityDoesNotHaveSuchAction) {
+		t.Errorf("Expected ErrEntityDoesNotHaveSuchAction, got %v", err)
+	}
+}
+
+func TestWhoCanWithRequireRule(t *testing.T) {
+	schema := newQueryTestSchema(t)
+
+	entity, _ := schema.getEntity("user")
+	resource, _ := schema.getResource("cache")
+	ctx := NewAuthorizationContext(entity, "delete", resource)
+	schema.ActionGatePolicy.AddRule(NewActionGateRule(&ctx, RequireActionGateEffect, []Role{schema.Roles[0]}))
+
+	combinations, err := schema.WhoCan("user", "delete", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := combinationsToStrings(combinations)
+	expected := []string{"user+deleter", "user+admin"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWhatCan(t *testing.T) {
+	schema := newQueryTestSchema(t)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		expected []string
+	}{
+		{"admin", []string{"admin"}, []string{"user:delete:cache", "user:purge:cache", "user:read:cache"}},
+		{"restricted is denied by AGP", []string{"restricted"}, []string{}},
+		{"combined roles", []string{"deleter", "updater"}, []string{"user:delete:cache", "user:purge:cache"}},
+		{"no roles", []string{}, []string{}},
+	}
+
+	host := Host{Schemas: []Schema{schema}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permitted, err := host.WhatCan("svc", tt.roles)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(permitted) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, permitted)
+			}
+			for i, p := range permitted {
+				if p.String() != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, permitted)
+				}
+			}
+		})
+	}
+
+	if _, err := host.WhatCan("svc", []string{"root"}); err == nil {
+		t.Error("Expected error for unknown role")
+	}
+	if _, err := host.WhatCan("other", []string{"admin"}); err == nil {
+		t.Error("Expected error for unknown schema")
+	}
+}
+
+func TestQueriesIgnoreGlobalAuthorizer(t *testing.T) {
+	schema := newQueryTestSchema(t)
+
+	AddInterceptors(Interceptor{
+		Before: func(*AuthorizationContext, []Role) *Decision {
+			return &Decision{}
+		},
+	})
+	defer defaultAuthorizer.RemoveInterceptors()
+	SetAuthzFunc(AuthorizeAnyFunc)
+	defer SetAuthzFunc(AuthorizeCRUDFunc)
+
+	combinations, err := schema.WhoCan("user", "purge", "cache")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := combinationsToStrings(combinations); len(got) != 2 || got[0] != "admin" || got[1] != "deleter+updater" {
+		t.Errorf("Expected global authorizer to be ignored, got %v", got)
+	}
+	if permitted := schema.WhatCan([]Role{schema.Roles[2]}); len(permitted) != 1 || permitted[0].String() != "user:delete:cache" {
+		t.Errorf("Expected global authorizer to be ignored, got %v", permitted)
+	}
+}
@@ -1,11 +1,10 @@
 package rbac
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
-// TODO add partial loading (to be able for example to init all actions in code, but load AGP from config)
-
 // "raw" structs are designed to be used by host and schema to be able to be initialized from files.
 // They are more user-friendly, but also more "heavy".
 //
@@ -17,19 +16,23 @@ import (
 // but using rawPermissions the only way is to check all flag one-by-one in 'if' statements.
 
 type rawPermissions struct {
-	Create     bool `json:"create"`
-	SelfCreate bool `json:"self-create"`
-	Read       bool `json:"read"`
-	SelfRead   bool `json:"self-read"`
-	Update     bool `json:"update"`
-	SelfUpdate bool `json:"self-update"`
-	Delete     bool `json:"delete"`
-	SelfDelete bool `json:"self-delete"`
+	Create     bool `json:"create,omitempty"`
+	SelfCreate bool `json:"self-create,omitempty"`
+	Read       bool `json:"read,omitempty"`
+	SelfRead   bool `json:"self-read,omitempty"`
+	Update     bool `json:"update,omitempty"`
+	SelfUpdate bool `json:"self-update,omitempty"`
+	Delete     bool `json:"delete,omitempty"`
+	SelfDelete bool `json:"self-delete,omitempty"`
 }
 
-func (r rawPermissions) ToBitmask() Permissions {
+func (r *rawPermissions) ToBitmask() Permissions {
 	var permissions Permissions
 
+	if r == nil {
+		return permissions
+	}
+
 	if r.Create {
 		permissions |= CreatePermission
 	}
@@ -58,14 +61,55 @@ func (r rawPermissions) ToBitmask() Permissions {
 	return permissions
 }
 
+// Permissions can be specified either as object of flags, either as string in any format
+// supported by ParsePermissions, e.g. "read|self-update" or "cR-u".
+func (r *rawPermissions) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+
+		permissions, err := ParsePermissions(s)
+		if err != nil {
+			return err
+		}
+
+		*r = *newRawPermissions(permissions)
+
+		return nil
+	}
+
+	type flags rawPermissions
+
+	return json.Unmarshal(data, (*flags)(r))
+}
+
+func newRawPermissions(permissions Permissions) *rawPermissions {
+	return &rawPermissions{
+		Create:     permissions&CreatePermission != 0,
+		SelfCreate: permissions&SelfCreatePermission != 0,
+		Read:       permissions&ReadPermission != 0,
+		SelfRead:   permissions&SelfReadPermission != 0,
+		Update:     permissions&UpdatePermission != 0,
+		SelfUpdate: permissions&SelfUpdatePermission != 0,
+		Delete:     permissions&DeletePermission != 0,
+		SelfDelete: permissions&SelfDeletePermission != 0,
+	}
+}
+
 type rawRole struct {
 	Name        string          `json:"name"`
-	Permissions *rawPermissions `json:"permissions"`
+	Permissions *rawPermissions `json:"permissions,omitempty"`
+	// Merge strategy of this role with the role of the same name (see MergeStrategy).
+	Merge string `json:"merge,omitempty"`
 }
 
 type rawAction struct {
 	Name                string          `json:"name"`
-	RequiredPermissions *rawPermissions `json:"required-permissions"`
+	RequiredPermissions *rawPermissions `json:"required-permissions,omitempty"`
+	// Match strategy of this action (see MatchStrategy).
+	Match string `json:"match,omitempty"`
 }
 
 type rawActionGateRules struct {
@@ -81,9 +125,69 @@ type rawActionGateRules struct {
 	On string `json:"on"`
 }
 
+// Exactly one field must be specified.
+type rawUsersetRewrite struct {
+	This            bool                 `json:"this,omitempty"`
+	ComputedUserset string               `json:"computed-userset,omitempty"`
+	TupleToUserset  *rawTupleToUserset   `json:"tuple-to-userset,omitempty"`
+	Union           []*rawUsersetRewrite `json:"union,omitempty"`
+}
+
+type rawTupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computed-userset"`
+}
+
+type rawRelation struct {
+	Name string `json:"name"`
+	// Optional, if omitted relation consists only of directly written tuples.
+	Rewrite *rawUsersetRewrite `json:"rewrite,omitempty"`
+}
+
+type rawRelationType struct {
+	Type      string         `json:"type"`
+	Relations []*rawRelation `json:"relations"`
+}
+
+type rawRelationGrant struct {
+	// Entities
+	For []string `json:"for"`
+	// Actions
+	Doing []string `json:"doing"`
+	// Resource
+	On string `json:"on"`
+	// Relation
+	Through string `json:"through"`
+}
+
+type rawTenant struct {
+	ID               string                `json:"id"`
+	ActionGatePolicy []*rawActionGateRules `json:"action-gate-policy"`
+}
+
+type rawSuppression struct {
+	Rule   string `json:"rule"`
+	Target string `json:"target,omitempty"`
+}
+
+type rawCrossSchemaGrant struct {
+	From string `json:"from"`
+	// Entities
+	For []string `json:"for"`
+	// Actions
+	Doing []string `json:"doing"`
+	To    string   `json:"to"`
+	// Resources
+	On []string `json:"on"`
+	// Roles
+	Having []string `json:"having"`
+}
+
 type rawEntity struct {
 	Name    string       `json:"name"`
 	Actions []*rawAction `json:"actions"`
+	// Match strategy of the entity actions, which don't have their own one (see MatchStrategy).
+	Match string `json:"match,omitempty"`
 }
 
 type rawSchema struct {
@@ -93,6 +197,21 @@ type rawSchema struct {
 	Entities          []*rawEntity          `json:"entities,omitempty"`
 	Resources         []string              `json:"resources,omitempty"`
 	ActionGatePolicy  []*rawActionGateRules `json:"action-gate-policy,omitempty"`
+	Relations         []*rawRelationType    `json:"relations,omitempty"`
+	RelationGrants    []*rawRelationGrant   `json:"relation-grants,omitempty"`
+	Tenants           []*rawTenant          `json:"tenants,omitempty"`
+	Suppress          []*rawSuppression     `json:"suppress,omitempty"`
+	// Default merge strategy of the schema roles.
+	RoleMerge string `json:"role-merge,omitempty"`
+}
+
+func hasRawRole(roles []*rawRole, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 func normalizeRoles(rawRoles []*rawRole) []Role {
@@ -200,20 +319,151 @@ func normalizeActionGatePolicy(
 	return agp, nil
 }
 
-func normalizeEntities(rawEntities []*rawEntity) []Entity {
+func normalizeUsersetRewrite(raw *rawUsersetRewrite) (UsersetRewrite, error) {
+	if raw == nil {
+		return This(), nil
+	}
+
+	var rewrites []UsersetRewrite
+
+	if raw.This {
+		rewrites = append(rewrites, This())
+	}
+	if raw.ComputedUserset != "" {
+		rewrites = append(rewrites, ComputedUserset(raw.ComputedUserset))
+	}
+	if raw.TupleToUserset != nil {
+		if raw.TupleToUserset.Tupleset == "" || raw.TupleToUserset.ComputedUserset == "" {
+			return UsersetRewrite{}, fmt.Errorf("tuple-to-userset rewrite requires both tupleset and computed-userset")
+		}
+		rewrites = append(rewrites, TupleToUserset(raw.TupleToUserset.Tupleset, raw.TupleToUserset.ComputedUserset))
+	}
+	if raw.Union != nil {
+		children := make([]UsersetRewrite, 0, len(raw.Union))
+		for _, rawChild := range raw.Union {
+			child, err := normalizeUsersetRewrite(rawChild)
+			if err != nil {
+				return UsersetRewrite{}, err
+			}
+			children = append(children, child)
+		}
+		rewrites = append(rewrites, Union(children...))
+	}
+
+	if len(rewrites) != 1 {
+		return UsersetRewrite{}, fmt.Errorf("userset rewrite must have exactly one of: this, computed-userset, tuple-to-userset, union")
+	}
+
+	return rewrites[0], nil
+}
+
+// Returns nil if schema doesn't have relations.
+func normalizeRelations(
+	schemaEntities []Entity,
+	schemaResources []Resource,
+	rawTypes []*rawRelationType,
+	rawGrants []*rawRelationGrant,
+) (*RelationModel, error) {
+	if len(rawTypes) == 0 && len(rawGrants) == 0 {
+		return nil, nil
+	}
+
+	model := NewRelationModel()
+
+	for _, rawType := range rawTypes {
+		relationType := NewRelationType(rawType.Type)
+
+		for _, rawRelation := range rawType.Relations {
+			rewrite, err := normalizeUsersetRewrite(rawRelation.Rewrite)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid rewrite of the %s#%s relation - %s", rawType.Type, rawRelation.Name, err.Error())
+			}
+			if err := relationType.NewRelation(rawRelation.Name, rewrite); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := model.AddType(relationType); err != nil {
+			return nil, err
+		}
+	}
+
+	entityMap := make(map[string]*Entity, len(schemaEntities))
+	for i := range schemaEntities {
+		entityMap[schemaEntities[i].name] = &schemaEntities[i]
+	}
+
+	resourceMap := make(map[string]*Resource, len(schemaResources))
+	for i := range schemaResources {
+		resourceMap[schemaResources[i].name] = &schemaResources[i]
+	}
+
+	for _, rawGrant := range rawGrants {
+		resource, ok := resourceMap[rawGrant.On]
+		if !ok {
+			return nil, fmt.Errorf("Resource %s doesn't exist in the schema resources", rawGrant.On)
+		}
+
+		for _, entityName := range rawGrant.For {
+			entity, ok := entityMap[entityName]
+			if !ok {
+				return nil, fmt.Errorf("Failed to get normalized entity - \"%s\" doesn't exist", entityName)
+			}
+
+			for _, actionName := range rawGrant.Doing {
+				ctx := NewAuthorizationContext(entity, Action(actionName), resource)
+				if err := model.Grant(&ctx, rawGrant.Through); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return model, nil
+}
+
+func normalizeSuppressions(rawSuppressions []*rawSuppression) ([]Suppression, error) {
+	suppressions := make([]Suppression, 0, len(rawSuppressions))
+
+	for _, rawSuppression := range rawSuppressions {
+		if rawSuppression.Rule == "" {
+			return nil, fmt.Errorf("Suppression is missing rule")
+		}
+		suppressions = append(suppressions, Suppression{
+			Rule:   rawSuppression.Rule,
+			Target: rawSuppression.Target,
+		})
+	}
+
+	return suppressions, nil
+}
+
+func normalizeEntities(rawEntities []*rawEntity) ([]Entity, error) {
 	entities := make([]Entity, 0, len(rawEntities))
 
 	for _, rawEntity := range rawEntities {
 		entity := NewEntity(rawEntity.Name)
 
+		if rawEntity.Match != "" {
+			if err := entity.SetMatch(MatchStrategy(rawEntity.Match)); err != nil {
+				return nil, fmt.Errorf("Entity %s: %s", rawEntity.Name, err.Error())
+			}
+		}
+
 		for _, rawAct := range rawEntity.Actions {
-			entity.NewAction(rawAct.Name, rawAct.RequiredPermissions.ToBitmask())
+			act, err := entity.NewAction(rawAct.Name, rawAct.RequiredPermissions.ToBitmask())
+			if err != nil || rawAct.Match == "" {
+				continue
+			}
+			if err := entity.SetActionMatch(act, MatchStrategy(rawAct.Match)); err != nil {
+				return nil, fmt.Errorf("Action %s of the %s entity: %s", rawAct.Name, rawEntity.Name, err.Error())
+			}
 		}
 
 		entities = append(entities, entity)
 	}
 
-	return entities
+	return entities, nil
 }
 
 func normalizeResources(rawResources []string) []Resource {
@@ -230,6 +480,16 @@ func normalizeResources(rawResources []string) []Resource {
 
 // Creates new Schema based on self.
 func (s *rawSchema) Normalize() (Schema, error) {
+	entities, err := normalizeEntities(s.Entities)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return s.normalize(entities, normalizeResources(s.Resources))
+}
+
+// Creates new Schema based on self, but with the specified entities and resources instead of the raw ones.
+func (s *rawSchema) normalize(entities []Entity, resources []Resource) (Schema, error) {
 	Debug.Log("Normalizing schema...")
 
 	schema := Schema{}
@@ -245,8 +505,8 @@ func (s *rawSchema) Normalize() (Schema, error) {
 	}
 
 	schema.DefaultRoles = defaultRoles
-	schema.Entities = normalizeEntities(s.Entities)
-	schema.Resources = normalizeResources(s.Resources)
+	schema.Entities = entities
+	schema.Resources = resources
 
 	agp, err := normalizeActionGatePolicy(
 		schema.Entities,
@@ -260,6 +520,48 @@ func (s *rawSchema) Normalize() (Schema, error) {
 
 	schema.ActionGatePolicy = agp
 
+	relations, err := normalizeRelations(
+		schema.Entities,
+		schema.Resources,
+		s.Relations,
+		s.RelationGrants,
+	)
+	if err != nil {
+		return Schema{}, fmt.Errorf("Failed to normalize relations for the %s schema: %s", schema.ID, err.Error())
+	}
+
+	schema.Relations = relations
+
+	if len(s.Tenants) > 0 {
+		schema.TenantPolicies = make(map[string]ActionGatePolicy, len(s.Tenants))
+	}
+
+	for _, rawTenant := range s.Tenants {
+		if rawTenant.ID == "" {
+			return Schema{}, fmt.Errorf("Tenant ID is missing in the %s schema", schema.ID)
+		}
+		if _, ok := schema.TenantPolicies[rawTenant.ID]; ok {
+			return Schema{}, fmt.Errorf("Tenant %s is duplicated in the %s schema", rawTenant.ID, schema.ID)
+		}
+
+		tenantAgp, err := normalizeActionGatePolicy(
+			schema.Entities,
+			schema.Roles,
+			schema.Resources,
+			rawTenant.ActionGatePolicy,
+		)
+		if err != nil {
+			return Schema{}, fmt.Errorf("Failed to normalize Action Gate Policy of the %s tenant for the %s schema: %s", rawTenant.ID, schema.ID, err.Error())
+		}
+
+		schema.TenantPolicies[rawTenant.ID] = tenantAgp
+	}
+
+	schema.Suppressions, err = normalizeSuppressions(s.Suppress)
+	if err != nil {
+		return Schema{}, fmt.Errorf("Invalid suppressions in the %s schema: %s", schema.ID, err.Error())
+	}
+
 	Debug.Log("Normalizing schema: OK")
 
 	return schema, nil
@@ -281,9 +583,116 @@ func (s *rawSchema) NormalizeAndValidate() (Schema, error) {
 }
 
 type rawHost struct {
-	DefaultRolesNames []string     `json:"default-roles,omitempty"`
-	GlobalRoles       []*rawRole   `json:"roles"`
-	Schemas           []*rawSchema `json:"schemas"`
+	DefaultRolesNames []string          `json:"default-roles,omitempty"`
+	GlobalRoles       []*rawRole        `json:"roles,omitempty"`
+	Schemas           []*rawSchema      `json:"schemas"`
+	Suppress          []*rawSuppression `json:"suppress,omitempty"`
+	// Paths or glob patterns of the schema files, relative to the host file.
+	Include []string `json:"include,omitempty"`
+	// Default merge strategy of the roles of all schemas.
+	RoleMerge string `json:"role-merge,omitempty"`
+	// Rules which are applied to all schemas, see rawHost.schemaActionGatePolicy.
+	ActionGatePolicy []*rawActionGateRules `json:"action-gate-policy,omitempty"`
+	// What to do with host rules which reference names that some schema doesn't have (see MissingReferencesPolicy).
+	MissingReferences string `json:"action-gate-policy-missing,omitempty"`
+	// Service-to-service grants between schemas.
+	CrossSchemaGrants []*rawCrossSchemaGrant `json:"cross-schema-grants,omitempty"`
+
+	// Set by MergeRoles.
+	effectiveRoles map[string][]EffectiveRole
+}
+
+// Reports whether list has null entry, e.g. "roles": [null].
+func hasNilEntry[T any](entries []*T) bool {
+	for _, entry := range entries {
+		if entry == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNilRewrite(rewrite *rawUsersetRewrite) bool {
+	if rewrite == nil {
+		return false
+	}
+	for _, child := range rewrite.Union {
+		if child == nil || hasNilRewrite(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns error if some list of the schema has null entry.
+func (s *rawSchema) checkEntries() error {
+	lists := []struct {
+		name string
+		nil  bool
+	}{
+		{"roles", hasNilEntry(s.Roles)},
+		{"entities", hasNilEntry(s.Entities)},
+		{"action-gate-policy", hasNilEntry(s.ActionGatePolicy)},
+		{"relations", hasNilEntry(s.Relations)},
+		{"relation-grants", hasNilEntry(s.RelationGrants)},
+		{"tenants", hasNilEntry(s.Tenants)},
+		{"suppress", hasNilEntry(s.Suppress)},
+	}
+	for _, list := range lists {
+		if list.nil {
+			return fmt.Errorf("Schema %s has null entry in the %s list", s.ID, list.name)
+		}
+	}
+
+	for _, entity := range s.Entities {
+		if hasNilEntry(entity.Actions) {
+			return fmt.Errorf("Entity %s of the %s schema has null entry in the actions list", entity.Name, s.ID)
+		}
+	}
+	for _, t := range s.Relations {
+		if hasNilEntry(t.Relations) {
+			return fmt.Errorf("Relation type %s of the %s schema has null entry in the relations list", t.Type, s.ID)
+		}
+		for _, relation := range t.Relations {
+			if hasNilRewrite(relation.Rewrite) {
+				return fmt.Errorf("Relation %s#%s of the %s schema has null entry in the union list", t.Type, relation.Name, s.ID)
+			}
+		}
+	}
+	for _, tenant := range s.Tenants {
+		if hasNilEntry(tenant.ActionGatePolicy) {
+			return fmt.Errorf("Tenant %s of the %s schema has null entry in the action-gate-policy list", tenant.ID, s.ID)
+		}
+	}
+
+	return nil
+}
+
+// Returns error if some list of the host or its schemas has null entry.
+func (h *rawHost) checkEntries() error {
+	lists := []struct {
+		name string
+		nil  bool
+	}{
+		{"roles", hasNilEntry(h.GlobalRoles)},
+		{"schemas", hasNilEntry(h.Schemas)},
+		{"suppress", hasNilEntry(h.Suppress)},
+		{"action-gate-policy", hasNilEntry(h.ActionGatePolicy)},
+		{"cross-schema-grants", hasNilEntry(h.CrossSchemaGrants)},
+	}
+	for _, list := range lists {
+		if list.nil {
+			return fmt.Errorf("Host has null entry in the %s list", list.name)
+		}
+	}
+
+	for _, schema := range h.Schemas {
+		if err := schema.checkEntries(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Creates new Host based on self.
@@ -297,7 +706,15 @@ func (h *rawHost) Normalize() (Host, error) {
 	host.Schemas = make([]Schema, len(h.Schemas))
 
 	for i, rawSchema := range h.Schemas {
-		schema, err := rawSchema.Normalize()
+		rules, err := h.schemaActionGatePolicy(rawSchema)
+		if err != nil {
+			return zero, err
+		}
+
+		withHostRules := *rawSchema
+		withHostRules.ActionGatePolicy = rules
+
+		schema, err := withHostRules.Normalize()
 		if err != nil {
 			return zero, err
 		}
@@ -312,6 +729,19 @@ func (h *rawHost) Normalize() (Host, error) {
 		return zero, err
 	}
 
+	host.Suppressions, err = normalizeSuppressions(h.Suppress)
+	if err != nil {
+		return zero, fmt.Errorf("Invalid host suppressions: %s", err.Error())
+	}
+
+	host.effectiveRoles = h.effectiveRoles
+	host.index = newHostIndex(host.Schemas)
+
+	host.CrossSchemaGrants, err = normalizeCrossSchemaGrants(&host, h.CrossSchemaGrants)
+	if err != nil {
+		return zero, err
+	}
+
 	Debug.Log("Normalizing host: OK")
 
 	return host, nil
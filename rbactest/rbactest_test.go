@@ -0,0 +1,194 @@
+package rbactest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+const testHostConfig = `{
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true, "self-delete": true}},
+		{"name": "moderator", "permissions": {"read": true, "delete": true}},
+		{"name": "admin", "permissions": {"read": true, "update": true, "delete": true}}
+	],
+	"schemas": [{
+		"id": "auth",
+		"resources": ["cache"],
+		"entities": [{"name": "user", "actions": [
+			{"name": "read", "required-permissions": {"read": true}},
+			{"name": "delete", "required-permissions": {"delete": true}},
+			{"name": "update", "required-permissions": {"update": true}}
+		]}],
+		"action-gate-policy": [
+			{"for": ["user"], "having": ["admin"], "apply": "require", "doing": ["delete"], "on": "cache"},
+			{"for": ["user"], "having": ["user"], "apply": "deny", "doing": ["update"], "on": "cache"}
+		],
+		"tenants": [
+			{"id": "tenant-a", "action-gate-policy": [
+				{"for": ["user"], "having": ["moderator"], "apply": "deny", "doing": ["read"], "on": "cache"}
+			]}
+		]
+	}]
+}`
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func loadTestHost(t *testing.T) rbac.Host {
+	t.Helper()
+
+	host, err := rbac.LoadHost(writeFile(t, "RBAC.json", testHostConfig))
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	return host
+}
+
+func TestRun(t *testing.T) {
+	host := loadTestHost(t)
+
+	path := writeFile(t, "suite.json", `{
+		"schema": "auth",
+		"cases": [
+			{"name": "admin can delete", "roles": ["admin"], "entity": "user", "action": "delete", "resource": "cache", "expect": "allow"},
+			{"name": "moderator needs admin", "roles": ["moderator"], "entity": "user", "action": "delete", "resource": "cache", "error": "action-denied-by-agp"},
+			{"name": "user can't read", "roles": ["user"], "entity": "user", "action": "read", "resource": "cache", "expect": "deny"},
+			{"name": "moderator can read", "roles": ["moderator"], "entity": "user", "action": "read", "resource": "cache", "expect": "allow"},
+			{
+				"name": "moderator can't read in tenant-a",
+				"roles": ["moderator"], "entity": "user", "action": "read", "resource": "cache",
+				"attributes": {"tenant": "tenant-a"},
+				"error": "action-denied-by-agp"
+			},
+			{"name": "unknown action", "roles": ["admin"], "entity": "user", "action": "create", "resource": "cache", "error": "entity-does-not-have-such-action"}
+		]
+	}`)
+
+	result := Run(t, &host, path)
+
+	if len(result.Cases) != 6 {
+		t.Errorf("Expected 6 cases, got %d", len(result.Cases))
+	}
+
+	coverage := result.Coverage
+	if coverage.TotalRules != 3 || coverage.TotalActions != 3 {
+		t.Errorf("Unexpected totals %+v", coverage)
+	}
+	if len(coverage.UncoveredRules) != 1 || coverage.UncoveredRules[0] != "auth: user:update:cache" {
+		t.Errorf("Expected only user:update:cache rule to be uncovered, got %v", coverage.UncoveredRules)
+	}
+	if len(coverage.UncoveredActions) != 1 || coverage.UncoveredActions[0] != "auth: user:update" {
+		t.Errorf("Expected only user:update action to be uncovered, got %v", coverage.UncoveredActions)
+	}
+}
+
+func TestExecuteFailures(t *testing.T) {
+	host := loadTestHost(t)
+
+	suite, err := Parse([]byte(`{
+		"schema": "auth",
+		"cases": [
+			{"name": "wrong expectation", "roles": ["moderator"], "entity": "user", "action": "delete", "resource": "cache", "expect": "allow"},
+			{"name": "wrong error", "roles": ["user"], "entity": "user", "action": "read", "resource": "cache", "error": "action-denied-by-agp"},
+			{"name": "unknown schema", "schema": "billing", "roles": [], "entity": "user", "action": "read", "resource": "cache", "expect": "deny"},
+			{"name": "unknown role", "roles": ["root"], "entity": "user", "action": "read", "resource": "cache", "expect": "deny"},
+			{"name": "unknown entity", "roles": [], "entity": "bot", "action": "read", "resource": "cache", "expect": "deny"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse suite: %v", err)
+	}
+
+	result := Execute(&host, suite)
+
+	failed := result.Failed()
+	if len(failed) != 5 {
+		t.Fatalf("Expected all 5 cases to fail, got %d", len(failed))
+	}
+
+	expected := []string{
+		"expected allow, but denied by action-gate-policy; AGP rule: require (admin)",
+		"expected error \"action-denied-by-agp\", but denied by permissions",
+		"schema with id \"billing\" wasn't found",
+		"schema \"auth\" doesn't have role \"root\"",
+		"schema \"auth\" doesn't have entity \"bot\"",
+	}
+
+	for i, c := range failed {
+		if !strings.HasPrefix(c.Message, expected[i]) {
+			t.Errorf("Expected message to start with %q, got %q", expected[i], c.Message)
+		}
+	}
+}
+
+func TestExecuteWith(t *testing.T) {
+	host := loadTestHost(t)
+
+	suite, err := Parse([]byte(`{
+		"schema": "auth",
+		"cases": [
+			{"name": "admin can read", "roles": ["admin"], "entity": "user", "action": "read", "resource": "cache", "expect": "allow"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse suite: %v", err)
+	}
+
+	lockdown := rbac.Interceptor{
+		Before: func(*rbac.AuthorizationContext, []rbac.Role) *rbac.Decision {
+			return &rbac.Decision{}
+		},
+	}
+
+	// Global authorization function doesn't affect results of the suite.
+	rbac.SetAuthzFunc(func(rbac.Permissions, rbac.Permissions) error { return rbac.ErrInsufficientPermissions })
+	result := Execute(&host, suite)
+	rbac.SetAuthzFunc(rbac.AuthorizeCRUDFunc)
+	if failed := result.Failed(); len(failed) != 0 {
+		t.Errorf("Expected global authorization function to be ignored, got %s", failed[0].Message)
+	}
+
+	authorizer := rbac.NewAuthorizer()
+	authorizer.AddInterceptors(lockdown)
+
+	suite.Cases[0].Expect = ""
+	suite.Cases[0].Error = "denied-by-interceptor"
+	if failed := ExecuteWith(&host, suite, authorizer).Failed(); len(failed) != 0 {
+		t.Errorf("Expected case to be denied by interceptor of the authorizer, got %s", failed[0].Message)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		suite string
+	}{
+		{"unknown field", `{"cases": [], "foo": 1}`},
+		{"missing name", `{"cases": [{"entity": "user", "action": "read", "resource": "cache", "expect": "allow"}]}`},
+		{"missing action", `{"cases": [{"name": "a", "entity": "user", "resource": "cache", "expect": "allow"}]}`},
+		{"invalid expectation", `{"cases": [{"name": "a", "entity": "user", "action": "read", "resource": "cache", "expect": "maybe"}]}`},
+		{"unknown error", `{"cases": [{"name": "a", "entity": "user", "action": "read", "resource": "cache", "error": "oops"}]}`},
+		{"allow with error", `{"cases": [{"name": "a", "entity": "user", "action": "read", "resource": "cache", "expect": "allow", "error": "insufficient-permissions"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.suite)); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package rbactest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+// Errors which can be expected by test cases.
+var Errors = map[string]error{
+	"insufficient-permissions":         rbac.ErrInsufficientPermissions,
+	"action-denied-by-agp":             rbac.ErrActionDeniedByAGP,
+	"entity-does-not-have-such-action": rbac.ErrEntityDoesNotHaveSuchAction,
+	"cross-schema-access-not-granted":  rbac.ErrCrossSchemaAccessNotGranted,
+	"denied-by-interceptor":            rbac.ErrDeniedByInterceptor,
+	"tenant-missing":                   rbac.ErrTenantMissing,
+	"rule-provider-failed":             rbac.ErrRuleProviderFailed,
+	"relation-depth-exceeded":          rbac.ErrRelationDepthExceeded,
+}
+
+type CaseResult struct {
+	Case   Case
+	Passed bool
+	// Zero value if case wasn't evaluated (e.g. schema doesn't have such entity).
+	Decision rbac.Decision
+	// Why case failed, empty if it passed.
+	Message string
+}
+
+// Coverage shows what wasn't exercised by the test suite.
+// Only schemas which are used by the suite are taken into account.
+type Coverage struct {
+	// Action Gate Policy rules in the form "schema: entity:action:resource" (or "schema[tenant]: ..." for tenant rules).
+	UncoveredRules []string
+	// Actions in the form "schema: entity:action".
+	UncoveredActions []string
+	TotalRules       int
+	TotalActions     int
+}
+
+type Result struct {
+	Cases    []CaseResult
+	Coverage Coverage
+}
+
+func (r Result) Failed() []CaseResult {
+	var failed []CaseResult
+	for _, c := range r.Cases {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// Explains the decision in human-readable form.
+func Explain(decision rbac.Decision) string {
+	var b strings.Builder
+
+	if decision.Allowed {
+		b.WriteString("allowed")
+	} else {
+		b.WriteString("denied")
+	}
+
+	if decision.Source != "" {
+		b.WriteString(" by " + string(decision.Source))
+	}
+
+	if decision.Rule != nil {
+		b.WriteString(
+			"; AGP rule: " + string(decision.Rule.Effect) +
+				" (" + strings.Join(rbac.GetRolesNames(decision.Rule.Roles), ", ") + ")",
+		)
+	}
+
+	b.WriteString("; required permissions: " + decision.Required.String())
+	b.WriteString(", granted permissions: " + decision.Granted.String())
+
+	if decision.Err != nil {
+		b.WriteString("; error: " + decision.Err.Error())
+	}
+
+	return b.String()
+}
+
+func findEntity(schema *rbac.Schema, name string) (*rbac.Entity, bool) {
+	for i := range schema.Entities {
+		if schema.Entities[i].Name() == name {
+			return &schema.Entities[i], true
+		}
+	}
+	return nil, false
+}
+
+func findResource(schema *rbac.Schema, name string) (*rbac.Resource, bool) {
+	for i := range schema.Resources {
+		if schema.Resources[i].Name() == name {
+			return &schema.Resources[i], true
+		}
+	}
+	return nil, false
+}
+
+type coverageTracker struct {
+	rules   map[*rbac.ActionGateRule]bool
+	actions map[string]bool
+	schemas map[string]*rbac.Schema
+}
+
+func (t *coverageTracker) report() Coverage {
+	var coverage Coverage
+
+	ids := make([]string, 0, len(t.schemas))
+	for id := range t.schemas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		schema := t.schemas[id]
+
+		for _, rule := range schema.ActionGatePolicy.Rules() {
+			coverage.TotalRules++
+			if !t.rules[rule] {
+				coverage.UncoveredRules = append(coverage.UncoveredRules, id+": "+ruleContext(rule))
+			}
+		}
+
+		tenants := make([]string, 0, len(schema.TenantPolicies))
+		for tenant := range schema.TenantPolicies {
+			tenants = append(tenants, tenant)
+		}
+		sort.Strings(tenants)
+
+		for _, tenant := range tenants {
+			for _, rule := range schema.TenantPolicies[tenant].Rules() {
+				coverage.TotalRules++
+				if !t.rules[rule] {
+					coverage.UncoveredRules = append(coverage.UncoveredRules, id+"["+tenant+"]: "+ruleContext(rule))
+				}
+			}
+		}
+
+		for _, entity := range schema.Entities {
+			for _, act := range entity.Actions() {
+				coverage.TotalActions++
+				key := id + ": " + entity.Name() + ":" + act.String()
+				if !t.actions[key] {
+					coverage.UncoveredActions = append(coverage.UncoveredActions, key)
+				}
+			}
+		}
+	}
+
+	return coverage
+}
+
+func ruleContext(rule *rbac.ActionGateRule) string {
+	return rule.Entity.Name() + ":" + rule.Action.String() + ":" + rule.Resource.Name()
+}
+
+func runCase(host *rbac.Host, authorizer *rbac.Authorizer, suite *Suite, c Case, tracker *coverageTracker) CaseResult {
+	result := CaseResult{Case: c}
+
+	schemaID := c.Schema
+	if schemaID == "" {
+		schemaID = suite.Schema
+	}
+
+	schema, err := host.GetSchema(schemaID)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	tracker.schemas[schema.ID] = schema
+
+	entity, ok := findEntity(schema, c.Entity)
+	if !ok {
+		result.Message = "schema \"" + schema.ID + "\" doesn't have entity \"" + c.Entity + "\""
+		return result
+	}
+
+	resource, ok := findResource(schema, c.Resource)
+	if !ok {
+		result.Message = "schema \"" + schema.ID + "\" doesn't have resource \"" + c.Resource + "\""
+		return result
+	}
+
+	roles := make([]rbac.Role, 0, len(c.Roles))
+	for _, name := range c.Roles {
+		role, err := schema.ParseRole(name)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		roles = append(roles, role)
+	}
+
+	ctx := rbac.NewAuthorizationContext(entity, rbac.Action(c.Action), resource)
+	if c.Attributes != nil {
+		ctx.Subject = c.Attributes.Subject
+		ctx.ResourceID = c.Attributes.ResourceID
+		ctx.Tenant = c.Attributes.Tenant
+	}
+
+	decision := authorizer.Decide(&ctx, roles, schema.Policy())
+	result.Decision = decision
+
+	if decision.Rule != nil {
+		tracker.rules[decision.Rule] = true
+	}
+	if entity.HasAction(ctx.Action) {
+		tracker.actions[schema.ID+": "+entity.Name()+":"+c.Action] = true
+	}
+
+	switch {
+	case c.Error != "":
+		if !errors.Is(decision.Err, Errors[c.Error]) {
+			result.Message = "expected error \"" + c.Error + "\", but " + Explain(decision)
+			return result
+		}
+	case c.Expect == ExpectAllow && !decision.Allowed:
+		result.Message = "expected allow, but " + Explain(decision)
+		return result
+	case c.Expect == ExpectDeny && decision.Allowed:
+		result.Message = "expected deny, but " + Explain(decision)
+		return result
+	}
+
+	result.Passed = true
+
+	return result
+}
+
+// Runs each case of the suite through the authorization against the host.
+// Cases are authorized by a private authorizer, so global interceptors, authorization function
+// and relations don't affect results (see ExecuteWith).
+func Execute(host *rbac.Host, suite Suite) Result {
+	return ExecuteWith(host, suite, nil)
+}
+
+// Same as Execute, but cases are authorized by the specified authorizer (private one if it's nil).
+func ExecuteWith(host *rbac.Host, suite Suite, authorizer *rbac.Authorizer) Result {
+	if authorizer == nil {
+		authorizer = rbac.NewAuthorizer()
+	}
+
+	tracker := &coverageTracker{
+		rules:   map[*rbac.ActionGateRule]bool{},
+		actions: map[string]bool{},
+		schemas: map[string]*rbac.Schema{},
+	}
+
+	result := Result{
+		Cases: make([]CaseResult, 0, len(suite.Cases)),
+	}
+
+	for _, c := range suite.Cases {
+		result.Cases = append(result.Cases, runCase(host, authorizer, &suite, c, tracker))
+	}
+
+	result.Coverage = tracker.report()
+
+	return result
+}
+
+// Loads test suite from the file and runs each its case as a subtest.
+// Cases which failed are reported with explanation of the decision, coverage is logged.
+func Run(t *testing.T, host *rbac.Host, path string) Result {
+	t.Helper()
+	return RunWith(t, host, path, nil)
+}
+
+// Same as Run, but cases are authorized by the specified authorizer (see ExecuteWith).
+func RunWith(t *testing.T, host *rbac.Host, path string, authorizer *rbac.Authorizer) Result {
+	t.Helper()
+
+	suite, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load test suite %s: %v", path, err)
+	}
+
+	result := ExecuteWith(host, suite, authorizer)
+
+	for _, c := range result.Cases {
+		c := c
+		t.Run(c.Case.Name, func(t *testing.T) {
+			if !c.Passed {
+				t.Error(c.Message)
+			}
+		})
+	}
+
+	for _, rule := range result.Coverage.UncoveredRules {
+		t.Logf("Action Gate Policy rule is not covered: %s", rule)
+	}
+	for _, act := range result.Coverage.UncoveredActions {
+		t.Logf("Action is not covered: %s", act)
+	}
+
+	return result
+}
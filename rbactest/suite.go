@@ -0,0 +1,124 @@
+// Package rbactest runs declarative test suites against RBAC host configuration.
+//
+// Test suite is a JSON file:
+//
+//	{
+//	    "schema": "auth-service",
+//	    "cases": [
+//	        {
+//	            "name": "admin can delete cache",
+//	            "roles": ["admin"],
+//	            "entity": "user",
+//	            "action": "delete",
+//	            "resource": "cache",
+//	            "expect": "allow"
+//	        },
+//	        {
+//	            "name": "moderator is denied by AGP",
+//	            "roles": ["moderator"],
+//	            "entity": "user",
+//	            "action": "delete",
+//	            "resource": "cache",
+//	            "error": "action-denied-by-agp"
+//	        }
+//	    ]
+//	}
+//
+// Schema can be also specified for each case separately.
+package rbactest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type Expectation string
+
+const (
+	ExpectAllow Expectation = "allow"
+	ExpectDeny  Expectation = "deny"
+)
+
+// Attributes are optional fields of the authorization context.
+type Attributes struct {
+	Subject    string `json:"subject,omitempty"`
+	ResourceID string `json:"resource-id,omitempty"`
+	Tenant     string `json:"tenant,omitempty"`
+}
+
+type Case struct {
+	Name string `json:"name"`
+	// Optional, if omitted then schema of the suite is used.
+	Schema     string      `json:"schema,omitempty"`
+	Roles      []string    `json:"roles"`
+	Entity     string      `json:"entity"`
+	Action     string      `json:"action"`
+	Resource   string      `json:"resource"`
+	Attributes *Attributes `json:"attributes,omitempty"`
+	// Either "allow" or "deny". May be omitted if error is specified, since it implies "deny".
+	Expect Expectation `json:"expect,omitempty"`
+	// Optional. Expected error, see Errors for all possible values.
+	Error string `json:"error,omitempty"`
+}
+
+type Suite struct {
+	// Default schema for all cases.
+	Schema string `json:"schema,omitempty"`
+	Cases  []Case `json:"cases"`
+}
+
+// Validates the case, schema is not checked.
+func (c *Case) Validate() error {
+	if c.Name == "" {
+		return errors.New("case name is missing")
+	}
+	if c.Entity == "" || c.Action == "" || c.Resource == "" {
+		return fmt.Errorf("case \"%s\": entity, action and resource are required", c.Name)
+	}
+	if c.Error != "" {
+		if _, ok := Errors[c.Error]; !ok {
+			return fmt.Errorf("case \"%s\": unknown error \"%s\"", c.Name, c.Error)
+		}
+		if c.Expect == ExpectAllow {
+			return fmt.Errorf("case \"%s\": can't expect both allow and error", c.Name)
+		}
+		return nil
+	}
+	if c.Expect != ExpectAllow && c.Expect != ExpectDeny {
+		return fmt.Errorf("case \"%s\": expect must be either \"allow\" or \"deny\"", c.Name)
+	}
+	return nil
+}
+
+// Parses test suite from JSON and validates all its cases.
+func Parse(data []byte) (Suite, error) {
+	var suite Suite
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&suite); err != nil {
+		return Suite{}, errors.New("Failed to parse test suite: " + err.Error())
+	}
+
+	for i := range suite.Cases {
+		if err := suite.Cases[i].Validate(); err != nil {
+			return Suite{}, err
+		}
+	}
+
+	return suite, nil
+}
+
+// Reads and parses test suite from the file at the specified path.
+func Load(path string) (Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Suite{}, err
+	}
+
+	return Parse(data)
+}
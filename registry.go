@@ -0,0 +1,171 @@
+package rbac
+
+// schemaIndex is a position of the schema in the host and positions of its entities, resources and roles.
+//
+// Index is built when host is loaded and never modified after that, so it's safe for concurrent use.
+// If host was modified after loading, then index may be stale: each lookup verifies found element
+// and falls back to the linear search if it doesn't match.
+type schemaIndex struct {
+	position  int
+	entities  map[string]int
+	resources map[string]int
+	roles     map[string]int
+}
+
+func newHostIndex(schemas []Schema) map[string]*schemaIndex {
+	index := make(map[string]*schemaIndex, len(schemas))
+
+	for i := range schemas {
+		schema := &schemas[i]
+
+		idx := &schemaIndex{
+			position:  i,
+			entities:  make(map[string]int, len(schema.Entities)),
+			resources: make(map[string]int, len(schema.Resources)),
+			roles:     make(map[string]int, len(schema.Roles)),
+		}
+		for j, entity := range schema.Entities {
+			idx.entities[entity.name] = j
+		}
+		for j, resource := range schema.Resources {
+			idx.resources[resource.name] = j
+		}
+		for j, role := range schema.Roles {
+			idx.roles[role.Name] = j
+		}
+
+		index[schema.ID] = idx
+	}
+
+	return index
+}
+
+// Returns position of the element with the specified name, using index if it's not stale.
+func lookup(index map[string]int, name string, n int, nameAt func(i int) string) (int, bool) {
+	if i, ok := index[name]; ok && i < n && nameAt(i) == name {
+		return i, true
+	}
+
+	for i := 0; i < n; i++ {
+		if nameAt(i) == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func (h *Host) lookupSchema(id string) (*Schema, *schemaIndex, bool) {
+	if idx, ok := h.index[id]; ok && idx.position < len(h.Schemas) && h.Schemas[idx.position].ID == id {
+		return &h.Schemas[idx.position], idx, true
+	}
+
+	for i := range h.Schemas {
+		if h.Schemas[i].ID == id {
+			// Schema isn't indexed (or index is stale), so all lookups will be linear.
+			return &h.Schemas[i], &schemaIndex{}, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+func (schema *Schema) lookupEntity(idx *schemaIndex, name string) (*Entity, error) {
+	i, ok := lookup(idx.entities, name, len(schema.Entities), func(i int) string {
+		return schema.Entities[i].name
+	})
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrEntityNotFound, Schema: schema.ID, Name: name}
+	}
+	return &schema.Entities[i], nil
+}
+
+func (schema *Schema) lookupResource(idx *schemaIndex, name string) (*Resource, error) {
+	i, ok := lookup(idx.resources, name, len(schema.Resources), func(i int) string {
+		return schema.Resources[i].name
+	})
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrResourceNotFound, Schema: schema.ID, Name: name}
+	}
+	return &schema.Resources[i], nil
+}
+
+func (schema *Schema) lookupRoles(idx *schemaIndex, names []string) ([]Role, error) {
+	roles := make([]Role, len(names))
+	for i, name := range names {
+		j, ok := lookup(idx.roles, name, len(schema.Roles), func(i int) string {
+			return schema.Roles[i].Name
+		})
+		if !ok {
+			return nil, &NotFoundError{Kind: ErrRoleNotFound, Schema: schema.ID, Name: name}
+		}
+		roles[i] = schema.Roles[j]
+	}
+	return roles, nil
+}
+
+// Resolves names into authorization context and roles of the schema.
+func (h *Host) resolve(
+	schemaID string,
+	entityName string,
+	act Action,
+	resourceName string,
+	roleNames []string,
+) (*Schema, AuthorizationContext, []Role, error) {
+	schema, idx, ok := h.lookupSchema(schemaID)
+	if !ok {
+		return nil, AuthorizationContext{}, nil, &NotFoundError{Kind: ErrSchemaNotFound, Name: schemaID}
+	}
+
+	entity, err := schema.lookupEntity(idx, entityName)
+	if err != nil {
+		return nil, AuthorizationContext{}, nil, err
+	}
+
+	resource, err := schema.lookupResource(idx, resourceName)
+	if err != nil {
+		return nil, AuthorizationContext{}, nil, err
+	}
+
+	roles, err := schema.lookupRoles(idx, roleNames)
+	if err != nil {
+		return nil, AuthorizationContext{}, nil, err
+	}
+
+	return schema, NewAuthorizationContext(entity, act, resource), roles, nil
+}
+
+// Authorizes action of the entity on the resource for the specified roles,
+// all of them are referenced by their names in the schema with the specified ID.
+// Policy of the schema (see Schema.Policy) is applied automatically, authorization is performed by the host
+// authorizer (see Host.Authorizer).
+//
+// Returns NotFoundError if schema, entity, resource or any of the roles doesn't exist,
+// and ErrEntityDoesNotHaveSuchAction if entity doesn't have such action.
+func (h *Host) Authorize(schemaID string, entityName string, act Action, resourceName string, roleNames []string) error {
+	return h.Decide(schemaID, entityName, act, resourceName, roleNames).Err
+}
+
+// Same as Host.Authorize, but returns detailed result of authorization.
+func (h *Host) Decide(schemaID string, entityName string, act Action, resourceName string, roleNames []string) Decision {
+	a := h.authorizer()
+
+	schema, ctx, roles, err := h.resolve(schemaID, entityName, act, resourceName, roleNames)
+	if err != nil {
+		return a.decideNotFound(entityName, act, resourceName, err)
+	}
+
+	return a.Decide(&ctx, roles, schema.Policy())
+}
+
+// Returns decision for the request, which references unknown names, with interceptors of the authorizer
+// applied to it, so they observe (and may short-circuit) such requests as well. Since names can't be resolved,
+// interceptors receive context with entity and resource which have only names, and no roles.
+func (a *Authorizer) decideNotFound(entityName string, act Action, resourceName string, err error) Decision {
+	entity := NewEntity(entityName)
+	ctx := NewAuthorizationContext(&entity, act, NewResource(resourceName))
+
+	return a.decideWith(&ctx, nil, 0, func() Decision {
+		return Decision{Err: err}
+	})
+}
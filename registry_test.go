@@ -0,0 +1,171 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+)
+
+const registryTestHost = `{
+	"roles": [
+		{"name": "user", "permissions": {"self-read": true}},
+		{"name": "admin", "permissions": {"read": true, "delete": true}}
+	],
+	"schemas": [
+		{
+			"id": "billing",
+			"entities": [{"name": "invoice", "actions": [{"name": "read", "required-permissions": {"read": true}}]}],
+			"resources": ["payments"]
+		},
+		{
+			"id": "storage",
+			"roles": [{"name": "restricted", "permissions": {"read": true, "delete": true}}],
+			"entities": [
+				{"name": "file", "actions": [
+					{"name": "read", "required-permissions": {"read": true}},
+					{"name": "delete", "required-permissions": {"delete": true}}
+				]}
+			],
+			"resources": ["disk"],
+			"action-gate-policy": [
+				{"for": ["file"], "doing": ["delete"], "on": "disk", "apply": "deny", "having": ["restricted"]}
+			]
+		}
+	]
+}`
+
+func TestHostAuthorize(t *testing.T) {
+	host, err := loadMergeTestHost(t, registryTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		entity   string
+		act      Action
+		resource string
+		roles    []string
+		expected error
+	}{
+		{"allowed", "billing", "invoice", "read", "payments", []string{"admin"}, nil},
+		{"insufficient permissions", "billing", "invoice", "read", "payments", []string{"user"}, ErrInsufficientPermissions},
+		{"schema policy", "storage", "file", "delete", "disk", []string{"restricted"}, ErrActionDeniedByAGP},
+		{"global role in other schema", "storage", "file", "delete", "disk", []string{"admin"}, nil},
+		{"unknown action", "billing", "invoice", "delete", "payments", []string{"admin"}, ErrEntityDoesNotHaveSuchAction},
+		{"unknown schema", "search", "invoice", "read", "payments", []string{"admin"}, ErrSchemaNotFound},
+		{"unknown entity", "billing", "file", "read", "payments", []string{"admin"}, ErrEntityNotFound},
+		{"unknown resource", "billing", "invoice", "read", "disk", []string{"admin"}, ErrResourceNotFound},
+		{"role of other schema", "billing", "invoice", "read", "payments", []string{"restricted"}, ErrRoleNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := host.Authorize(test.schema, test.entity, test.act, test.resource, test.roles)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestHostAuthorizeNotFoundError(t *testing.T) {
+	host, err := loadMergeTestHost(t, registryTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	err = host.Authorize("billing", "invoice", "read", "payments", []string{"admin", "ghost"})
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Expected NotFoundError, got %v", err)
+	}
+	if notFound.Schema != "billing" || notFound.Name != "ghost" {
+		t.Errorf("Unexpected error fields: %+v", notFound)
+	}
+	if expected := `schema "billing" doesn't have role "ghost"`; err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+
+	decision := host.Decide("billing", "invoice", "read", "payments", []string{"admin"})
+	if decision.Err != nil || decision.Granted != ReadPermission|DeletePermission {
+		t.Errorf("Unexpected decision: %+v", decision)
+	}
+}
+
+func TestHostIndexFallback(t *testing.T) {
+	host, err := loadMergeTestHost(t, registryTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	// Make index stale: schemas are reordered and "billing" gets a new role in front of the others.
+	host.Schemas[0], host.Schemas[1] = host.Schemas[1], host.Schemas[0]
+
+	billing, err := host.GetSchema("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	billing.Roles = append([]Role{NewRole("reader", ReadPermission)}, billing.Roles...)
+
+	if host.Schemas[1].ID != "billing" || len(host.Schemas[1].Roles) != 3 {
+		t.Fatalf("GetSchema must return schema stored in the host")
+	}
+
+	for _, role := range []string{"reader", "admin"} {
+		if err := host.Authorize("billing", "invoice", "read", "payments", []string{role}); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", role, err)
+		}
+	}
+	if err := host.Authorize("storage", "file", "delete", "disk", []string{"restricted"}); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected %v, got %v", ErrActionDeniedByAGP, err)
+	}
+
+	// Host built manually has no index at all.
+	manual := Host{Schemas: host.Schemas}
+	if err := manual.Authorize("billing", "invoice", "read", "payments", []string{"reader"}); err != nil {
+		t.Errorf("Expected manually built host to authorize, got %v", err)
+	}
+	if _, err := manual.GetSchema("search"); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("Expected %v, got %v", ErrSchemaNotFound, err)
+	}
+}
+
+func TestHostAuthorizer(t *testing.T) {
+	host, err := loadMergeTestHost(t, crossSchemaTestHost)
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	var intercepted []string
+	authorizer := NewAuthorizer()
+	authorizer.AddInterceptors(Interceptor{
+		Before: func(ctx *AuthorizationContext, roles []Role) *Decision {
+			intercepted = append(intercepted, ctx.Entity.Name()+":"+ctx.Action.String())
+			return &Decision{}
+		},
+	})
+
+	host.Authorizer = authorizer
+
+	errs := []error{
+		host.Authorize("billing", "service", "read", "invoice", []string{"auditor"}),
+		host.AuthorizeCrossSchema("billing", "service", "read", "auth-service", "user", []string{"service"}),
+		host.Authorize("search", "service", "read", "invoice", nil),
+	}
+	for i, err := range errs {
+		if !errors.Is(err, ErrDeniedByInterceptor) {
+			t.Errorf("Expected request %d to be denied by interceptor of the host authorizer, got %v", i, err)
+		}
+	}
+	if len(intercepted) != len(errs) {
+		t.Errorf("Expected %d intercepted requests, got %v", len(errs), intercepted)
+	}
+
+	// Default authorizer is used if host doesn't have its own one.
+	host.Authorizer = nil
+	if err := host.Authorize("billing", "service", "read", "invoice", []string{"auditor"}); err != nil {
+		t.Errorf("Expected action to be allowed by default authorizer, got %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Relationships are an optional addition to roles, inspired by Zanzibar.
+//
+// Roles answer "what is this entity allowed to do in general", relationships answer
+// "how is this particular subject related to this particular object", e.g.
+// "alice is a member of the team that owns this repo".
+//
+// Relationships are stored as tuples in the form: object#relation@subject, for example:
+//
+//	repo:sentinel#owner@team:backend#member
+//	team:backend#member@user:alice
+
+var (
+	ErrRelationNotDefined    = errors.New("relation is not defined in the relation model")
+	ErrRelationDepthExceeded = errors.New("relation check exceeded maximum depth")
+	ErrInvalidRelationTuple  = errors.New("invalid relation tuple")
+)
+
+// Max depth of nested relation lookups, used to protect from cycles in tuples and rewrites.
+const maxRelationCheckDepth = 32
+
+// ObjectRef identifies a single object, e.g. "repo:sentinel".
+type ObjectRef struct {
+	Type string
+	ID   string
+}
+
+func (o ObjectRef) String() string {
+	return o.Type + ":" + o.ID
+}
+
+// SubjectRef is either a concrete object (e.g. "user:alice"),
+// either a set of subjects which have relation with object (e.g. "team:backend#member").
+type SubjectRef struct {
+	Object ObjectRef
+	// If not empty, then this subject is a userset.
+	Relation string
+}
+
+func (s SubjectRef) String() string {
+	if s.Relation == "" {
+		return s.Object.String()
+	}
+	return s.Object.String() + "#" + s.Relation
+}
+
+func (s SubjectRef) IsUserset() bool {
+	return s.Relation != ""
+}
+
+// Tuple represents a relationship between object and subject.
+type Tuple struct {
+	Object   ObjectRef
+	Relation string
+	Subject  SubjectRef
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+func parseObjectRef(s string) (ObjectRef, bool) {
+	objType, id, ok := strings.Cut(s, ":")
+	if !ok || objType == "" || id == "" {
+		return ObjectRef{}, false
+	}
+	return ObjectRef{Type: objType, ID: id}, true
+}
+
+// Parses subject in form "type:id" or "type:id#relation".
+func ParseSubjectRef(s string) (SubjectRef, error) {
+	objectPart, relation, hasRelation := strings.Cut(s, "#")
+
+	object, ok := parseObjectRef(objectPart)
+	if !ok || (hasRelation && relation == "") {
+		return SubjectRef{}, fmt.Errorf("%w: invalid subject \"%s\"", ErrInvalidRelationTuple, s)
+	}
+
+	return SubjectRef{Object: object, Relation: relation}, nil
+}
+
+// Parses tuple in form "type:id#relation@subject".
+func ParseTuple(s string) (Tuple, error) {
+	objectPart, subjectPart, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("%w: \"%s\" is missing subject", ErrInvalidRelationTuple, s)
+	}
+
+	objectRefPart, relation, ok := strings.Cut(objectPart, "#")
+	if !ok || relation == "" {
+		return Tuple{}, fmt.Errorf("%w: \"%s\" is missing relation", ErrInvalidRelationTuple, s)
+	}
+
+	object, ok := parseObjectRef(objectRefPart)
+	if !ok {
+		return Tuple{}, fmt.Errorf("%w: invalid object \"%s\"", ErrInvalidRelationTuple, objectRefPart)
+	}
+
+	subject, err := ParseSubjectRef(subjectPart)
+	if err != nil {
+		return Tuple{}, err
+	}
+
+	return Tuple{Object: object, Relation: relation, Subject: subject}, nil
+}
+
+// TupleStore persists relation tuples.
+type TupleStore interface {
+	Write(tuples ...Tuple) error
+	Delete(tuples ...Tuple) error
+	// Returns all subjects which have specified relation with the object.
+	Read(object ObjectRef, relation string) ([]SubjectRef, error)
+}
+
+// MemoryTupleStore is an in-memory implementation of TupleStore. Safe for concurrent use.
+type MemoryTupleStore struct {
+	mu     sync.RWMutex
+	tuples map[string][]SubjectRef
+}
+
+func NewMemoryTupleStore() *MemoryTupleStore {
+	return &MemoryTupleStore{
+		tuples: map[string][]SubjectRef{},
+	}
+}
+
+func (s *MemoryTupleStore) keyFrom(object ObjectRef, relation string) string {
+	return object.String() + "#" + relation
+}
+
+// Adds tuples into the store, already existing tuples are ignored.
+func (s *MemoryTupleStore) Write(tuples ...Tuple) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tuple := range tuples {
+		key := s.keyFrom(tuple.Object, tuple.Relation)
+
+		exists := false
+		for _, subject := range s.tuples[key] {
+			if subject == tuple.Subject {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			s.tuples[key] = append(s.tuples[key], tuple.Subject)
+		}
+	}
+
+	return nil
+}
+
+// Removes tuples from the store, non-existing tuples are ignored.
+func (s *MemoryTupleStore) Delete(tuples ...Tuple) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tuple := range tuples {
+		key := s.keyFrom(tuple.Object, tuple.Relation)
+		subjects := s.tuples[key]
+
+		for i, subject := range subjects {
+			if subject == tuple.Subject {
+				s.tuples[key] = append(subjects[:i:i], subjects[i+1:]...)
+				break
+			}
+		}
+
+		if len(s.tuples[key]) == 0 {
+			delete(s.tuples, key)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryTupleStore) Read(object ObjectRef, relation string) ([]SubjectRef, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subjects := s.tuples[s.keyFrom(object, relation)]
+
+	result := make([]SubjectRef, len(subjects))
+	copy(result, subjects)
+
+	return result, nil
+}
+
+type rewriteKind uint8
+
+const (
+	thisRewrite rewriteKind = iota
+	computedUsersetRewrite
+	tupleToUsersetRewrite
+	unionRewrite
+)
+
+// UsersetRewrite describes how subjects of the relation are computed.
+// Relation without rewrite consists only of the directly written tuples (same as This()).
+type UsersetRewrite struct {
+	kind     rewriteKind
+	relation string
+	tupleset string
+	children []UsersetRewrite
+}
+
+// Subjects from the tuples written directly for this relation.
+func This() UsersetRewrite {
+	return UsersetRewrite{kind: thisRewrite}
+}
+
+// Subjects of another relation of the same object.
+// (e.g. every "owner" of the repo is also its "reader")
+func ComputedUserset(relation string) UsersetRewrite {
+	return UsersetRewrite{kind: computedUsersetRewrite, relation: relation}
+}
+
+// Subjects which have specified relation with objects from tupleset relation of this object.
+// (e.g. every "member" of the repo's "parent" team is a "reader" of the repo)
+func TupleToUserset(tupleset string, relation string) UsersetRewrite {
+	return UsersetRewrite{kind: tupleToUsersetRewrite, relation: relation, tupleset: tupleset}
+}
+
+// Subjects from any of the given rewrites.
+func Union(rewrites ...UsersetRewrite) UsersetRewrite {
+	return UsersetRewrite{kind: unionRewrite, children: rewrites}
+}
+
+type RelationType struct {
+	name      string
+	relations map[string]UsersetRewrite
+}
+
+func NewRelationType(name string) *RelationType {
+	return &RelationType{
+		name:      name,
+		relations: map[string]UsersetRewrite{},
+	}
+}
+
+func (t *RelationType) Name() string {
+	return t.name
+}
+
+// Defines relation with the given rewrite on this type.
+// Will return error if relation with this name already exists.
+func (t *RelationType) NewRelation(name string, rewrite UsersetRewrite) error {
+	if _, ok := t.relations[name]; ok {
+		return errors.New("\"" + t.name + "\" type already has \"" + name + "\" relation")
+	}
+
+	t.relations[name] = rewrite
+
+	return nil
+}
+
+func (t *RelationType) HasRelation(name string) bool {
+	_, ok := t.relations[name]
+	return ok
+}
+
+// RelationModel describes object types, theirs relations and
+// which actions can be granted through these relations.
+type RelationModel struct {
+	types  map[string]*RelationType
+	grants map[string]string
+}
+
+func NewRelationModel() *RelationModel {
+	return &RelationModel{
+		types:  map[string]*RelationType{},
+		grants: map[string]string{},
+	}
+}
+
+// Adds type into the model, will return error if type with the same name already exists.
+func (m *RelationModel) AddType(t *RelationType) error {
+	if _, ok := m.types[t.name]; ok {
+		return errors.New("relation type \"" + t.name + "\" already exists in relation model")
+	}
+
+	m.types[t.name] = t
+
+	return nil
+}
+
+func (m *RelationModel) GetType(name string) (*RelationType, bool) {
+	t, ok := m.types[name]
+	return t, ok
+}
+
+func (m *RelationModel) keyFrom(entity *Entity, act Action, resource *Resource) string {
+	return entity.name + ":" + act.String() + ":" + resource.name
+}
+
+// Allows to perform action in the given context if subject has specified relation with the resource.
+// Resource name is used as object type, so type with the same name must exist in the model.
+func (m *RelationModel) Grant(ctx *AuthorizationContext, relation string) error {
+	t, ok := m.types[ctx.Resource.name]
+	if !ok {
+		return errors.New("relation type \"" + ctx.Resource.name + "\" doesn't exist in relation model")
+	}
+	if !t.HasRelation(relation) {
+		return errors.New("relation type \"" + t.name + "\" doesn't have \"" + relation + "\" relation")
+	}
+	if !ctx.Entity.HasAction(ctx.Action) {
+		return errors.New("\"" + ctx.Entity.name + "\" entity doesn't have \"" + ctx.Action.String() + "\" action")
+	}
+
+	key := m.keyFrom(ctx.Entity, ctx.Action, ctx.Resource)
+
+	if _, ok := m.grants[key]; ok {
+		return errors.New("relation grant for " + key + " already exists in relation model")
+	}
+
+	m.grants[key] = relation
+
+	return nil
+}
+
+// Returns relation which grants action in the given context.
+func (m *RelationModel) GetGrant(ctx *AuthorizationContext) (string, bool) {
+	relation, ok := m.grants[m.keyFrom(ctx.Entity, ctx.Action, ctx.Resource)]
+	return relation, ok
+}
+
+// RelationChecker evaluates relation model against tuples from the store.
+type RelationChecker struct {
+	model *RelationModel
+	store TupleStore
+}
+
+func NewRelationChecker(model *RelationModel, store TupleStore) *RelationChecker {
+	if model == nil {
+		panic("relation model can't be nil")
+	}
+	if store == nil {
+		panic("tuple store can't be nil")
+	}
+	return &RelationChecker{
+		model: model,
+		store: store,
+	}
+}
+
+// Checks if subject has relation with the object.
+// Cycles in the tuples are allowed: relation which is already being checked is considered as not satisfied on repeat visit.
+func (c *RelationChecker) Check(object ObjectRef, relation string, subject SubjectRef) (bool, error) {
+	return c.check(object, relation, subject, 0, map[string]bool{})
+}
+
+// visited contains "type:id#relation" of all already checked (or being checked) relations,
+// if relation was satisfied, then check would have already finished, so repeat visit is never satisfied.
+func (c *RelationChecker) check(object ObjectRef, relation string, subject SubjectRef, depth int, visited map[string]bool) (bool, error) {
+	if depth > maxRelationCheckDepth {
+		return false, ErrRelationDepthExceeded
+	}
+
+	key := object.Type + ":" + object.ID + "#" + relation
+	if visited[key] {
+		return false, nil
+	}
+	visited[key] = true
+
+	t, ok := c.model.types[object.Type]
+	if !ok {
+		return false, fmt.Errorf("%w: type \"%s\"", ErrRelationNotDefined, object.Type)
+	}
+
+	rewrite, ok := t.relations[relation]
+	if !ok {
+		return false, fmt.Errorf("%w: \"%s#%s\"", ErrRelationNotDefined, object.Type, relation)
+	}
+
+	return c.eval(rewrite, object, relation, subject, depth, visited)
+}
+
+func (c *RelationChecker) eval(rewrite UsersetRewrite, object ObjectRef, relation string, subject SubjectRef, depth int, visited map[string]bool) (bool, error) {
+	switch rewrite.kind {
+	case thisRewrite:
+		subjects, err := c.store.Read(object, relation)
+		if err != nil {
+			return false, err
+		}
+		// Direct matches are checked first, so they don't depend on the nested usersets.
+		for _, s := range subjects {
+			if s == subject {
+				return true, nil
+			}
+		}
+		for _, s := range subjects {
+			if !s.IsUserset() {
+				continue
+			}
+			ok, err := c.check(s.Object, s.Relation, subject, depth+1, visited)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case computedUsersetRewrite:
+		return c.check(object, rewrite.relation, subject, depth+1, visited)
+	case tupleToUsersetRewrite:
+		subjects, err := c.store.Read(object, rewrite.tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range subjects {
+			ok, err := c.check(s.Object, rewrite.relation, subject, depth+1, visited)
+			if err != nil {
+				// Objects in tupleset may be of types which don't have such relation, just skip them.
+				if errors.Is(err, ErrRelationNotDefined) {
+					continue
+				}
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	case unionRewrite:
+		for _, child := range rewrite.children {
+			ok, err := c.eval(child, object, relation, subject, depth, visited)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	default:
+		panic("unknown userset rewrite kind")
+	}
+}
+
+// Checks if action in the given context is granted through relation.
+// Subject is built from entity name and ctx.Subject, object - from resource name and ctx.ResourceID.
+func (c *RelationChecker) CheckContext(ctx *AuthorizationContext) (bool, error) {
+	relation, ok := c.model.GetGrant(ctx)
+	if !ok || ctx.Subject == "" || ctx.ResourceID == "" {
+		return false, nil
+	}
+
+	return c.Check(
+		ObjectRef{Type: ctx.Resource.name, ID: ctx.ResourceID},
+		relation,
+		SubjectRef{Object: ObjectRef{Type: ctx.Entity.name, ID: ctx.Subject}},
+	)
+}
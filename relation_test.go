@@ -0,0 +1,311 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustParseTuple(t *testing.T, s string) Tuple {
+	t.Helper()
+	tuple, err := ParseTuple(s)
+	if err != nil {
+		t.Fatalf("Failed to parse tuple %s: %v", s, err)
+	}
+	return tuple
+}
+
+func newRepoRelationModel(t *testing.T) *RelationModel {
+	t.Helper()
+
+	team := NewRelationType("team")
+	team.NewRelation("member", This())
+
+	repo := NewRelationType("repo")
+	repo.NewRelation("parent", This())
+	repo.NewRelation("owner", This())
+	repo.NewRelation("reader", Union(
+		This(),
+		ComputedUserset("owner"),
+		TupleToUserset("parent", "member"),
+	))
+
+	model := NewRelationModel()
+	if err := model.AddType(team); err != nil {
+		t.Fatalf("Failed to add type: %v", err)
+	}
+	if err := model.AddType(repo); err != nil {
+		t.Fatalf("Failed to add type: %v", err)
+	}
+
+	return model
+}
+
+func TestParseTuple(t *testing.T) {
+	tuple := mustParseTuple(t, "repo:sentinel#owner@team:backend#member")
+
+	if tuple.Object != (ObjectRef{Type: "repo", ID: "sentinel"}) {
+		t.Errorf("Unexpected object %s", tuple.Object)
+	}
+	if tuple.Relation != "owner" {
+		t.Errorf("Unexpected relation %s", tuple.Relation)
+	}
+	if !tuple.Subject.IsUserset() || tuple.Subject.String() != "team:backend#member" {
+		t.Errorf("Unexpected subject %s", tuple.Subject)
+	}
+	if tuple.String() != "repo:sentinel#owner@team:backend#member" {
+		t.Errorf("Unexpected string representation %s", tuple.String())
+	}
+
+	invalid := []string{
+		"repo:sentinel#owner",
+		"repo:sentinel@user:alice",
+		"repo#owner@user:alice",
+		"repo:sentinel#owner@user",
+		"repo:sentinel#owner@user:alice#",
+	}
+
+	for _, s := range invalid {
+		if _, err := ParseTuple(s); !errors.Is(err, ErrInvalidRelationTuple) {
+			t.Errorf("Expected ErrInvalidRelationTuple for %s, got %v", s, err)
+		}
+	}
+}
+
+func TestMemoryTupleStore(t *testing.T) {
+	store := NewMemoryTupleStore()
+	tuple := mustParseTuple(t, "repo:sentinel#owner@user:alice")
+
+	store.Write(tuple, tuple)
+
+	subjects, _ := store.Read(tuple.Object, tuple.Relation)
+	if len(subjects) != 1 {
+		t.Fatalf("Expected 1 subject, got %d", len(subjects))
+	}
+
+	store.Delete(tuple)
+
+	subjects, _ = store.Read(tuple.Object, tuple.Relation)
+	if len(subjects) != 0 {
+		t.Errorf("Expected no subjects after delete, got %d", len(subjects))
+	}
+}
+
+func TestRelationCheck(t *testing.T) {
+	store := NewMemoryTupleStore()
+	store.Write(
+		mustParseTuple(t, "repo:sentinel#owner@user:alice"),
+		mustParseTuple(t, "repo:sentinel#parent@team:backend"),
+		mustParseTuple(t, "team:backend#member@user:bob"),
+		mustParseTuple(t, "repo:sentinel#reader@team:qa#member"),
+		mustParseTuple(t, "team:qa#member@user:carol"),
+	)
+
+	checker := NewRelationChecker(newRepoRelationModel(t), store)
+	repo := ObjectRef{Type: "repo", ID: "sentinel"}
+
+	tests := []struct {
+		name     string
+		relation string
+		subject  string
+		expected bool
+	}{
+		{"direct owner", "owner", "user:alice", true},
+		{"computed userset", "reader", "user:alice", true},
+		{"tuple to userset", "reader", "user:bob", true},
+		{"userset tuple", "reader", "user:carol", true},
+		{"userset itself", "reader", "team:qa#member", true},
+		{"not owner", "owner", "user:bob", false},
+		{"stranger", "reader", "user:dave", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := ParseSubjectRef(tt.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := checker.Check(repo, tt.relation, subject)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ok != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, ok)
+			}
+		})
+	}
+
+	if _, err := checker.Check(repo, "admin", SubjectRef{Object: ObjectRef{Type: "user", ID: "alice"}}); !errors.Is(err, ErrRelationNotDefined) {
+		t.Errorf("Expected ErrRelationNotDefined, got %v", err)
+	}
+}
+
+func TestRelationCheckCycle(t *testing.T) {
+	group := NewRelationType("group")
+	group.NewRelation("member", This())
+
+	model := NewRelationModel()
+	model.AddType(group)
+
+	store := NewMemoryTupleStore()
+	store.Write(
+		mustParseTuple(t, "group:a#member@group:b#member"),
+		mustParseTuple(t, "group:b#member@group:a#member"),
+		mustParseTuple(t, "group:a#member@user:alice"),
+	)
+
+	checker := NewRelationChecker(model, store)
+
+	tests := []struct {
+		group    string
+		subject  string
+		expected bool
+	}{
+		{"a", "user:alice", true},
+		{"b", "user:alice", true},
+		{"a", "user:bob", false},
+		{"b", "user:bob", false},
+	}
+
+	for _, tt := range tests {
+		subject, _ := ParseSubjectRef(tt.subject)
+		ok, err := checker.Check(ObjectRef{Type: "group", ID: tt.group}, "member", subject)
+		if err != nil || ok != tt.expected {
+			t.Errorf("Expected %s membership in group:%s to be %v, got %v (%v)", tt.subject, tt.group, tt.expected, ok, err)
+		}
+	}
+
+	// Long chain without cycles is still limited by depth.
+	chain := NewMemoryTupleStore()
+	for i := 0; i <= maxRelationCheckDepth+1; i++ {
+		chain.Write(mustParseTuple(t, fmt.Sprintf("group:%d#member@group:%d#member", i, i+1)))
+	}
+
+	_, err := NewRelationChecker(model, chain).Check(ObjectRef{Type: "group", ID: "0"}, "member", SubjectRef{Object: ObjectRef{Type: "user", ID: "alice"}})
+	if !errors.Is(err, ErrRelationDepthExceeded) {
+		t.Errorf("Expected ErrRelationDepthExceeded, got %v", err)
+	}
+}
+
+func TestAuthorizeThroughRelation(t *testing.T) {
+	user := NewEntity("user")
+	readAction, _ := user.NewAction("read", ReadPermission)
+	repo := NewResource("repo")
+	userRole := NewRole("user", SelfReadPermission)
+
+	model := newRepoRelationModel(t)
+	grantCtx := NewAuthorizationContext(&user, readAction, repo)
+	if err := model.Grant(&grantCtx, "reader"); err != nil {
+		t.Fatalf("Failed to grant relation: %v", err)
+	}
+	if err := model.Grant(&grantCtx, "owner"); err == nil {
+		t.Error("Duplicate grant should error")
+	}
+
+	store := NewMemoryTupleStore()
+	store.Write(mustParseTuple(t, "repo:sentinel#owner@user:alice"))
+
+	authorizer := NewAuthorizer()
+	authorizer.SetRelationChecker(NewRelationChecker(model, store))
+
+	tests := []struct {
+		name      string
+		subject   string
+		expectErr error
+	}{
+		{"granted through relation", "alice", nil},
+		{"no relation", "bob", ErrInsufficientPermissions},
+		{"no subject", "", ErrInsufficientPermissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewAuthorizationContext(&user, readAction, repo)
+			ctx.Subject = tt.subject
+			ctx.ResourceID = "sentinel"
+
+			err := authorizer.Authorize(&ctx, []Role{userRole}, nil)
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("Expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	// AGP deny must take precedence over relations
+	agp := NewActionGatePolicy()
+	agp.AddRule(NewActionGateRule(&grantCtx, DenyActionGateEffect, []Role{userRole}))
+
+	ctx := NewAuthorizationContext(&user, readAction, repo)
+	ctx.Subject = "alice"
+	ctx.ResourceID = "sentinel"
+
+	if err := authorizer.Authorize(&ctx, []Role{userRole}, &agp); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected ErrActionDeniedByAGP, got %v", err)
+	}
+}
+
+func TestLoadSchemaWithRelations(t *testing.T) {
+	config := `{
+		"id": "repos",
+		"roles": [{"name": "user", "permissions": {"self-read": true}}],
+		"resources": ["repo", "team"],
+		"entities": [{"name": "user", "actions": [{"name": "read", "required-permissions": {"read": true}}]}],
+		"relations": [
+			{"type": "team", "relations": [{"name": "member"}]},
+			{"type": "repo", "relations": [
+				{"name": "parent"},
+				{"name": "owner"},
+				{"name": "reader", "rewrite": {"union": [
+					{"this": true},
+					{"computed-userset": "owner"},
+					{"tuple-to-userset": {"tupleset": "parent", "computed-userset": "member"}}
+				]}}
+			]}
+		],
+		"relation-grants": [{"for": ["user"], "doing": ["read"], "on": "repo", "through": "reader"}]
+	}`
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	if schema.Relations == nil {
+		t.Fatal("Schema relations should be loaded")
+	}
+
+	store := NewMemoryTupleStore()
+	store.Write(
+		mustParseTuple(t, "repo:sentinel#parent@team:backend"),
+		mustParseTuple(t, "team:backend#member@user:bob"),
+	)
+
+	authorizer := NewAuthorizer()
+	authorizer.SetRelationChecker(NewRelationChecker(schema.Relations, store))
+
+	ctx := NewAuthorizationContext(&schema.Entities[0], "read", &schema.Resources[0])
+	ctx.Subject = "bob"
+	ctx.ResourceID = "sentinel"
+
+	if err := authorizer.Authorize(&ctx, schema.Roles, nil); err != nil {
+		t.Errorf("Expected access through team membership, got %v", err)
+	}
+
+	invalid := `{
+		"id": "repos",
+		"resources": ["repo"],
+		"relations": [{"type": "repo", "relations": [{"name": "reader", "rewrite": {"computed-userset": "owner"}}]}]
+	}`
+	if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSchema(path); err == nil {
+		t.Error("Expected error for rewrite referencing undefined relation")
+	}
+}
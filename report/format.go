@@ -0,0 +1,154 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	MarkdownFormat Format = "md"
+	CSVFormat      Format = "csv"
+	HTMLFormat     Format = "html"
+)
+
+// Writes matrices in the specified format.
+func Write(w io.Writer, format Format, matrices []Matrix) error {
+	switch format {
+	case MarkdownFormat:
+		return WriteMarkdown(w, matrices)
+	case CSVFormat:
+		return WriteCSV(w, matrices)
+	case HTMLFormat:
+		return WriteHTML(w, matrices)
+	default:
+		return errors.New("unknown report format \"" + string(format) + "\"")
+	}
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// Writes each matrix as a Markdown table, where rows are contexts and columns are roles.
+func WriteMarkdown(w io.Writer, matrices []Matrix) error {
+	var b strings.Builder
+
+	b.WriteString("# Permission matrix\n")
+
+	for _, matrix := range matrices {
+		b.WriteString("\n## " + escapeMarkdown(matrix.Schema) + "\n\n")
+
+		if len(matrix.Rows) == 0 {
+			b.WriteString("Schema doesn't have any entity:action:resource contexts.\n")
+			continue
+		}
+
+		b.WriteString("| Context |")
+		for _, role := range matrix.Roles {
+			b.WriteString(" " + escapeMarkdown(role) + " |")
+		}
+		b.WriteString("\n| --- |")
+		for range matrix.Roles {
+			b.WriteString(" --- |")
+		}
+		b.WriteString("\n")
+
+		for _, row := range matrix.Rows {
+			b.WriteString("| `" + row.Context.String() + "` |")
+			for _, cell := range row.Cells {
+				b.WriteString(" " + cell.Decision() + " (" + cell.Reason() + ") |")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes all matrices as a single CSV table with one decision per line.
+func WriteCSV(w io.Writer, matrices []Matrix) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"schema", "role", "entity", "action", "resource", "decision", "source", "effect"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, matrix := range matrices {
+		for _, row := range matrix.Rows {
+			for _, cell := range row.Cells {
+				record := []string{
+					matrix.Schema,
+					cell.Role,
+					row.Context.Entity,
+					row.Context.Action.String(),
+					row.Context.Resource,
+					cell.Decision(),
+					string(cell.Source),
+					string(cell.Effect),
+				}
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Permission matrix</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+td.allow { background: #e3f6e3; }
+td.deny { background: #fbe3e3; }
+td small { color: #555; }
+code { font-size: 0.95em; }
+</style>
+</head>
+<body>
+<h1>Permission matrix</h1>
+{{- range . }}
+<h2>{{ .Schema }}</h2>
+{{- if .Rows }}
+<table>
+<thead>
+<tr><th>Context</th>{{ range .Roles }}<th>{{ . }}</th>{{ end }}</tr>
+</thead>
+<tbody>
+{{- range .Rows }}
+<tr><td><code>{{ .Context.String }}</code></td>{{ range .Cells }}<td class="{{ .Decision }}">{{ .Decision }} <small>({{ .Reason }})</small></td>{{ end }}</tr>
+{{- end }}
+</tbody>
+</table>
+{{- else }}
+<p>Schema doesn't have any entity:action:resource contexts.</p>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
+
+// Writes all matrices as a self-contained HTML page.
+func WriteHTML(w io.Writer, matrices []Matrix) error {
+	if err := htmlTemplate.Execute(w, matrices); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
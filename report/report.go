@@ -0,0 +1,109 @@
+// Package report renders permission matrices of RBAC schemas.
+//
+// Matrix shows decision for each role of the schema in each entity:action:resource context,
+// and also what determined this decision: permissions of the role or Action Gate Policy rule.
+package report
+
+import (
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+// Cell is a decision for a single role in a single context.
+type Cell struct {
+	Role    string
+	Allowed bool
+	Source  rbac.DecisionSource
+	// Effect of the Action Gate Policy rule which determined decision, empty if decision came from permissions.
+	Effect rbac.ActionGateEffect
+}
+
+// Explains why decision was made, e.g. "permissions" or "AGP: require".
+func (c Cell) Reason() string {
+	if c.Source == rbac.ActionGatePolicyDecisionSource {
+		return "AGP: " + string(c.Effect)
+	}
+	return string(c.Source)
+}
+
+func (c Cell) Decision() string {
+	if c.Allowed {
+		return "allow"
+	}
+	return "deny"
+}
+
+type Row struct {
+	Context rbac.PermittedAction
+	// In the same order as roles of the matrix.
+	Cells []Cell
+}
+
+// Matrix is a role x (entity:action:resource) permission matrix of a single schema.
+type Matrix struct {
+	Schema string
+	Roles  []string
+	Rows   []Row
+}
+
+// Builds permission matrix for the schema.
+// Each role is checked on its own, using policy of the schema (without tenants).
+// Private authorizer is used, so global interceptors, authorization function and relations don't affect the matrix.
+func FromSchema(schema *rbac.Schema) Matrix {
+	matrix := Matrix{
+		Schema: schema.ID,
+		Roles:  rbac.GetRolesNames(schema.Roles),
+	}
+
+	provider := schema.Policy()
+	authorizer := rbac.NewAuthorizer()
+
+	for i := range schema.Entities {
+		entity := &schema.Entities[i]
+
+		for _, act := range entity.Actions() {
+			for j := range schema.Resources {
+				resource := &schema.Resources[j]
+				ctx := rbac.NewAuthorizationContext(entity, act, resource)
+
+				row := Row{
+					Context: rbac.PermittedAction{
+						Entity:   entity.Name(),
+						Action:   act,
+						Resource: resource.Name(),
+					},
+					Cells: make([]Cell, len(schema.Roles)),
+				}
+
+				for k, role := range schema.Roles {
+					decision := authorizer.Decide(&ctx, []rbac.Role{role}, provider)
+
+					cell := Cell{
+						Role:    role.Name,
+						Allowed: decision.Allowed,
+						Source:  decision.Source,
+					}
+					if decision.Source == rbac.ActionGatePolicyDecisionSource {
+						cell.Effect = decision.Rule.Effect
+					}
+
+					row.Cells[k] = cell
+				}
+
+				matrix.Rows = append(matrix.Rows, row)
+			}
+		}
+	}
+
+	return matrix
+}
+
+// Builds permission matrix for each schema of the host.
+func FromHost(host *rbac.Host) []Matrix {
+	matrices := make([]Matrix, len(host.Schemas))
+
+	for i := range host.Schemas {
+		matrices[i] = FromSchema(&host.Schemas[i])
+	}
+
+	return matrices
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	rbac "github.com/abaxoth0/SentinelRBAC"
+)
+
+func newTestHost(t *testing.T) rbac.Host {
+	t.Helper()
+
+	user := rbac.NewEntity("user")
+	deleteAction, _ := user.NewAction("delete", rbac.DeletePermission)
+	user.NewAction("read", rbac.ReadPermission)
+	cache := rbac.NewResource("cache")
+
+	adminRole := rbac.NewRole("admin", rbac.ReadPermission|rbac.DeletePermission)
+	moderatorRole := rbac.NewRole("moderator", rbac.ReadPermission|rbac.DeletePermission)
+
+	agp := rbac.NewActionGatePolicy()
+	ctx := rbac.NewAuthorizationContext(&user, deleteAction, cache)
+	if err := agp.AddRule(rbac.NewActionGateRule(&ctx, rbac.RequireActionGateEffect, []rbac.Role{adminRole})); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := rbac.NewSchema("svc", []rbac.Role{adminRole, moderatorRole}, nil, agp)
+	schema.Entities = []rbac.Entity{user}
+	schema.Resources = []rbac.Resource{*cache}
+
+	return rbac.Host{Schemas: []rbac.Schema{schema}}
+}
+
+func TestFromHost(t *testing.T) {
+	host := newTestHost(t)
+	matrices := FromHost(&host)
+
+	if len(matrices) != 1 {
+		t.Fatalf("Expected 1 matrix, got %d", len(matrices))
+	}
+
+	matrix := matrices[0]
+	if matrix.Schema != "svc" || len(matrix.Roles) != 2 || len(matrix.Rows) != 2 {
+		t.Fatalf("Unexpected matrix %+v", matrix)
+	}
+
+	// Actions are sorted, so delete goes first
+	deleteRow := matrix.Rows[0]
+	if deleteRow.Context.String() != "user:delete:cache" {
+		t.Fatalf("Unexpected context %s", deleteRow.Context)
+	}
+
+	admin, moderator := deleteRow.Cells[0], deleteRow.Cells[1]
+	if !admin.Allowed || admin.Reason() != "permissions" {
+		t.Errorf("Expected admin to be allowed by permissions, got %+v", admin)
+	}
+	if moderator.Allowed || moderator.Reason() != "AGP: require" {
+		t.Errorf("Expected moderator to be denied by AGP require rule, got %+v", moderator)
+	}
+
+	readRow := matrix.Rows[1]
+	for _, cell := range readRow.Cells {
+		if !cell.Allowed || cell.Source != rbac.PermissionsDecisionSource {
+			t.Errorf("Expected %s to be allowed by permissions, got %+v", cell.Role, cell)
+		}
+	}
+}
+
+func TestFromSchemaIgnoresGlobalAuthorizer(t *testing.T) {
+	host := newTestHost(t)
+
+	rbac.SetAuthzFunc(func(required rbac.Permissions, permitted rbac.Permissions) error {
+		return rbac.ErrInsufficientPermissions
+	})
+	defer rbac.SetAuthzFunc(rbac.AuthorizeCRUDFunc)
+
+	matrix := FromSchema(&host.Schemas[0])
+	for _, cell := range matrix.Rows[1].Cells {
+		if !cell.Allowed {
+			t.Errorf("Expected %s to be allowed regardless of global authorization function, got %+v", cell.Role, cell)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	host := newTestHost(t)
+	matrices := FromHost(&host)
+
+	tests := []struct {
+		format   Format
+		expected []string
+	}{
+		{MarkdownFormat, []string{"## svc", "| Context | admin | moderator |", "| `user:delete:cache` | allow (permissions) | deny (AGP: require) |"}},
+		{HTMLFormat, []string{"<!DOCTYPE html>", "<h2>svc</h2>", "<th>moderator</th>", `<td class="deny">deny <small>(AGP: require)</small></td>`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, matrices); err != nil {
+				t.Fatalf("Failed to write report: %v", err)
+			}
+			for _, s := range tt.expected {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Expected report to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, Format("pdf"), matrices); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	host := newTestHost(t)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, FromHost(&host)); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	// Header + 2 contexts x 2 roles
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %d", len(records))
+	}
+
+	expected := []string{"svc", "moderator", "user", "delete", "cache", "deny", "action-gate-policy", "require"}
+	if strings.Join(records[2], ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, records[2])
+	}
+}
@@ -1,7 +1,5 @@
 package rbac
 
-import "errors"
-
 type Schema struct {
 	ID               string
 	Roles            []Role
@@ -9,6 +7,20 @@ type Schema struct {
 	Entities         []Entity
 	Resources        []Resource
 	ActionGatePolicy ActionGatePolicy
+	// Optional, nil if schema doesn't use relations.
+	Relations *RelationModel
+	// Optional per-tenant policies, which are layered on top of the ActionGatePolicy.
+	TenantPolicies map[string]ActionGatePolicy
+	// Findings of the static analysis which must be ignored.
+	Suppressions []Suppression
+}
+
+// Suppression disables finding of the static analysis.
+type Suppression struct {
+	// ID of the analysis rule, e.g. "RBAC001".
+	Rule string
+	// Optional. If empty, then all findings of the rule are suppressed.
+	Target string
 }
 
 func NewSchema(id string, roles []Role, defaultRoles []Role, agp ActionGatePolicy) Schema {
@@ -27,13 +39,53 @@ func (schema *Schema) ParseRole(roleName string) (Role, error) {
 		}
 	}
 
-	return Role{}, errors.New("schema \"" + schema.ID + "\" doesn't have role \"" + roleName + "\"")
+	return Role{}, &NotFoundError{Kind: ErrRoleNotFound, Schema: schema.ID, Name: roleName}
+}
+
+func (schema *Schema) getEntity(name string) (*Entity, bool) {
+	for i := range schema.Entities {
+		if schema.Entities[i].name == name {
+			return &schema.Entities[i], true
+		}
+	}
+	return nil, false
+}
+
+func (schema *Schema) getResource(name string) (*Resource, bool) {
+	for i := range schema.Resources {
+		if schema.Resources[i].name == name {
+			return &schema.Resources[i], true
+		}
+	}
+	return nil, false
+}
+
+// Returns rule provider which applies policy of the context tenant on top of the schema ActionGatePolicy.
+func (schema *Schema) Policy() RuleProvider {
+	return &TenantPolicy{
+		Base:    schema.ActionGatePolicy,
+		Tenants: schema.TenantPolicies,
+	}
 }
 
 // Reads and parses RBAC schema from file at the specified path.
+// Format of the file is determined by its extension (see ConfigFormatFromPath).
 // After loading and normalizing, it validates schema and returns an error if any of them were detected.
 func LoadSchema(path string) (Schema, error) {
-	schema, err := load[Schema, *rawSchema](path, nil)
+	return LoadSchemaFormat(path, ConfigFormatFromPath(path))
+}
+
+// Same as LoadSchema, but file is parsed in the specified format regardless of its extension.
+func LoadSchemaFormat(path string, format ConfigFormat) (Schema, error) {
+	return LoadSchemaOptions(path, LoadOptions{Format: format})
+}
+
+// Same as LoadSchema, but with the specified options.
+// Environment overlay is applied before normalization (see LoadOptions.Env).
+func LoadSchemaOptions(path string, opts LoadOptions) (Schema, error) {
+	schema, err := load(path, opts.format(path), func(raw **rawSchema) error {
+		return (*raw).compose(path, opts)
+	})
 	if err != nil {
 		return Schema{}, err
 	}
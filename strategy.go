@@ -0,0 +1,54 @@
+package rbac
+
+import "errors"
+
+// MatchStrategy defines how permissions of the roles are matched against permissions required by the action.
+type MatchStrategy string
+
+const (
+	// Roles must have all permissions required by the action. Used by default.
+	AllMatchStrategy MatchStrategy = "all"
+	// Roles must have at least one of the permissions required by the action.
+	// Action which doesn't require any permissions is always allowed.
+	AnyMatchStrategy MatchStrategy = "any"
+)
+
+func (s MatchStrategy) Validate() error {
+	switch s {
+	case AllMatchStrategy, AnyMatchStrategy:
+		return nil
+	default:
+		return errors.New("match strategy \"" + string(s) + "\" doesn't exist")
+	}
+}
+
+// StrategyFunc is a custom authorization strategy of the action (see Entity.SetActionStrategy).
+// It receives full authorization context and roles, and returns nil if action is allowed.
+type StrategyFunc func(ctx *AuthorizationContext, roles []Role) error
+
+// Checks if the "permitted" permissions contain at least one of the "required" permissions.
+//
+// It returns an "InsufficientPermissions" error if none of the "required" permissions are covered by the "permitted" permissions.
+func AuthorizeAnyFunc(required Permissions, permitted Permissions) error {
+	if required != 0 && required&permitted == 0 {
+		return ErrInsufficientPermissions
+	}
+
+	return nil
+}
+
+// Authorization strategy of the action or entity, either match or fn is set.
+type strategy struct {
+	match MatchStrategy
+	fn    StrategyFunc
+}
+
+func (s strategy) authorize(ctx *AuthorizationContext, roles []Role, required Permissions, permitted Permissions) error {
+	if s.fn != nil {
+		return s.fn(ctx, roles)
+	}
+	if s.match == AnyMatchStrategy {
+		return AuthorizeAnyFunc(required, permitted)
+	}
+	return AuthorizeCRUDFunc(required, permitted)
+}
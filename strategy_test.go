@@ -0,0 +1,222 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatchStrategies(t *testing.T) {
+	post := NewEntity("post")
+	readAction, _ := post.NewAction("read", ReadPermission|SelfReadPermission)
+	editAction, _ := post.NewAction("edit", ReadPermission|UpdatePermission)
+	publishAction, _ := post.NewAction("publish", UpdatePermission)
+	pingAction, _ := post.NewAction("ping", 0)
+
+	if err := post.SetMatch(AnyMatchStrategy); err != nil {
+		t.Fatal(err)
+	}
+	if err := post.SetActionMatch(editAction, AllMatchStrategy); err != nil {
+		t.Fatal(err)
+	}
+
+	errForbidden := errors.New("publishing is forbidden for this subject")
+	post.SetActionStrategy(publishAction, func(ctx *AuthorizationContext, roles []Role) error {
+		if ctx.Subject == "banned" {
+			return errForbidden
+		}
+		for _, role := range roles {
+			if role.Name == "editor" {
+				return nil
+			}
+		}
+		return ErrInsufficientPermissions
+	})
+
+	resource := NewResource("post")
+	reader := NewRole("reader", SelfReadPermission)
+	editor := NewRole("editor", UpdatePermission)
+
+	tests := []struct {
+		name     string
+		act      Action
+		subject  string
+		roles    []Role
+		expected error
+	}{
+		{"any of required permissions", readAction, "", []Role{reader}, nil},
+		{"none of required permissions", readAction, "", []Role{editor}, ErrInsufficientPermissions},
+		{"action overrides entity strategy", editAction, "", []Role{editor}, ErrInsufficientPermissions},
+		{"all of required permissions", editAction, "", []Role{editor, NewRole("r", ReadPermission)}, nil},
+		{"custom strategy allows", publishAction, "", []Role{editor}, nil},
+		{"custom strategy denies", publishAction, "", []Role{reader}, ErrInsufficientPermissions},
+		{"custom strategy error", publishAction, "banned", []Role{editor}, errForbidden},
+		{"nothing required", pingAction, "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewAuthorizationContext(&post, tt.act, resource)
+			ctx.Subject = tt.subject
+
+			if err := Authorize(&ctx, tt.roles, nil); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	post.SetStrategy(nil)
+	post.SetActionStrategy(publishAction, nil)
+	ctx := NewAuthorizationContext(&post, readAction, resource)
+	if err := Authorize(&ctx, []Role{reader}, nil); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("Expected default strategy after reset, got %v", err)
+	}
+}
+
+func TestStrategyOverridesAuthzFunc(t *testing.T) {
+	entity := NewEntity("user")
+	act, _ := entity.NewAction("read", ReadPermission|SelfReadPermission)
+	ctx := NewAuthorizationContext(&entity, act, NewResource("user"))
+	roles := []Role{NewRole("user", SelfReadPermission)}
+
+	authorizer := NewAuthorizer()
+	authorizer.SetAuthzFunc(func(Permissions, Permissions) error {
+		return nil
+	})
+
+	entity.SetActionMatch(act, AllMatchStrategy)
+	if err := authorizer.Authorize(&ctx, roles, nil); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("Expected action strategy to be used, got %v", err)
+	}
+}
+
+func TestEntityStrategyErrors(t *testing.T) {
+	entity := NewEntity("user")
+	act, _ := entity.NewAction("read", ReadPermission)
+
+	if err := entity.SetMatch("some"); err == nil {
+		t.Error("Expected error for unknown match strategy")
+	}
+	if err := entity.SetActionMatch(act, "some"); err == nil {
+		t.Error("Expected error for unknown match strategy")
+	}
+	if err := entity.SetActionMatch("delete", AnyMatchStrategy); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+	if err := entity.SetActionStrategy("delete", func(*AuthorizationContext, []Role) error { return nil }); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+
+	entity.SetActionMatch(act, AnyMatchStrategy)
+	if entity.ActionMatch(act) != AnyMatchStrategy || entity.Match() != "" {
+		t.Errorf("Unexpected match strategies: %q, %q", entity.ActionMatch(act), entity.Match())
+	}
+
+	entity.RemoveAction(act)
+	entity.NewAction("read", ReadPermission)
+	if entity.ActionMatch(act) != "" {
+		t.Error("Expected strategy to be removed with the action")
+	}
+
+	if entity.EffectiveMatch(act) != AllMatchStrategy {
+		t.Errorf("Expected all-bits matching by default, got %q", entity.EffectiveMatch(act))
+	}
+	entity.SetMatch(AnyMatchStrategy)
+	if entity.EffectiveMatch(act) != AnyMatchStrategy {
+		t.Errorf("Expected entity match strategy, got %q", entity.EffectiveMatch(act))
+	}
+	entity.SetActionStrategy(act, func(*AuthorizationContext, []Role) error { return nil })
+	if entity.EffectiveMatch(act) != "" {
+		t.Errorf("Expected no match strategy for custom strategy, got %q", entity.EffectiveMatch(act))
+	}
+}
+
+func TestLoadMatchStrategies(t *testing.T) {
+	config := `{
+		"roles": [{"name": "user", "permissions": "self-read"}],
+		"schemas": [{
+			"id": "blog",
+			"entities": [{"name": "post", "match": "any", "actions": [
+				{"name": "read", "required-permissions": "read|self-read"},
+				{"name": "edit", "required-permissions": "read|update", "match": "MATCH"}
+			]}],
+			"resources": ["post"]
+		}]
+	}`
+
+	host, err := loadMergeTestHost(t, strings.Replace(config, "MATCH", "all", 1))
+	if err != nil {
+		t.Fatalf("Failed to load host: %v", err)
+	}
+
+	if err := host.Authorize("blog", "post", "read", "post", []string{"user"}); err != nil {
+		t.Errorf("Expected read to be allowed, got %v", err)
+	}
+	if err := host.Authorize("blog", "post", "edit", "post", []string{"user"}); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("Expected edit to be denied, got %v", err)
+	}
+
+	if _, err := loadMergeTestHost(t, strings.Replace(config, "MATCH", "most", 1)); err == nil {
+		t.Error("Expected error for unknown match strategy")
+	}
+}
+
+func TestMatchStrategiesDSL(t *testing.T) {
+	src := `schema blog {
+    entity post {
+        action edit (all) requires read, update
+        match: any
+        action read requires read, self-read
+    }
+}`
+
+	expected := `schema blog {
+    entity post {
+        match: any
+        action edit (all) requires read, update
+        action read requires read, self-read
+    }
+}
+`
+
+	formatted, err := FormatDSL([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if string(formatted) != expected {
+		t.Errorf("Unexpected result:\n%s", formatted)
+	}
+
+	schema, err := ParseSchemaDSL([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	post := schema.Entities[0]
+	if post.Match() != AnyMatchStrategy || post.ActionMatch("edit") != AllMatchStrategy || post.ActionMatch("read") != "" {
+		t.Errorf("Unexpected match strategies of the entity: %+v", post)
+	}
+}
+
+func TestPartialEvaluateStrategies(t *testing.T) {
+	post := NewEntity("post")
+	readAction, _ := post.NewAction("read", ReadPermission|UpdatePermission)
+	post.SetActionMatch(readAction, AnyMatchStrategy)
+
+	ctx := NewAuthorizationContext(&post, readAction, NewResource("post"))
+	ctx.Subject = "42"
+
+	roles := []Role{NewRole("user", SelfReadPermission|SelfUpdatePermission)}
+
+	condition, err := PartialEvaluate(&ctx, roles, nil, PartialOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := Eq("owner", "42"); condition.String() != expected.String() {
+		t.Errorf("Expected %s, got %s", expected, condition)
+	}
+
+	post.SetActionStrategy(readAction, func(*AuthorizationContext, []Role) error { return nil })
+	if _, err := PartialEvaluate(&ctx, roles, nil, PartialOptions{}); !errors.Is(err, ErrCustomStrategyNotEvaluable) {
+		t.Errorf("Expected ErrCustomStrategyNotEvaluable, got %v", err)
+	}
+}
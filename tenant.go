@@ -0,0 +1,126 @@
+package rbac
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrTenantMissing = errors.New("authorization context doesn't have tenant or subject")
+
+// TenantRoles stores roles granted to subjects, separately for each tenant.
+// Roles granted in one tenant are never visible in another one. Safe for concurrent use.
+type TenantRoles struct {
+	mu     sync.RWMutex
+	grants map[string]map[string][]Role
+}
+
+func NewTenantRoles() *TenantRoles {
+	return &TenantRoles{
+		grants: map[string]map[string][]Role{},
+	}
+}
+
+// Grants roles to the subject in the specified tenant.
+// Roles which subject already has in this tenant are ignored.
+func (t *TenantRoles) Grant(tenant string, subject string, roles ...Role) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subjects, ok := t.grants[tenant]
+	if !ok {
+		subjects = map[string][]Role{}
+		t.grants[tenant] = subjects
+	}
+
+	roleMap := buildRoleMap(subjects[subject])
+
+	for _, role := range roles {
+		if _, ok := roleMap[role.Name]; ok {
+			continue
+		}
+		roleMap[role.Name] = role
+		subjects[subject] = append(subjects[subject], role)
+	}
+}
+
+// Revokes roles with specified names from the subject in the specified tenant.
+func (t *TenantRoles) Revoke(tenant string, subject string, roleNames ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subjects, ok := t.grants[tenant]
+	if !ok {
+		return
+	}
+
+	revoked := make(map[string]bool, len(roleNames))
+	for _, name := range roleNames {
+		revoked[name] = true
+	}
+
+	roles := make([]Role, 0, len(subjects[subject]))
+	for _, role := range subjects[subject] {
+		if !revoked[role.Name] {
+			roles = append(roles, role)
+		}
+	}
+
+	if len(roles) == 0 {
+		delete(subjects, subject)
+	} else {
+		subjects[subject] = roles
+	}
+
+	if len(subjects) == 0 {
+		delete(t.grants, tenant)
+	}
+}
+
+// Returns roles of the subject in the specified tenant.
+func (t *TenantRoles) Roles(tenant string, subject string) []Role {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	roles := t.grants[tenant][subject]
+
+	result := make([]Role, len(roles))
+	copy(result, roles)
+
+	return result
+}
+
+// TenantPolicy is a RuleProvider which layers per-tenant policies on top of the base policy.
+//
+// Rule for the context is looked up in the policy of the context tenant first,
+// if there are no such rule (or tenant doesn't have own policy), then rule from the base policy is used.
+type TenantPolicy struct {
+	Base    ActionGatePolicy
+	Tenants map[string]ActionGatePolicy
+}
+
+func (p *TenantPolicy) GetRule(ctx *AuthorizationContext) (*ActionGateRule, bool) {
+	if ctx.Tenant != "" {
+		if overlay, ok := p.Tenants[ctx.Tenant]; ok {
+			if rule, ok := overlay.GetRule(ctx); ok {
+				return rule, true
+			}
+		}
+	}
+
+	return p.Base.GetRule(ctx)
+}
+
+// Authorizes the context using roles which subject has in the context tenant.
+// Returns ErrTenantMissing if either ctx.Tenant, either ctx.Subject is empty.
+func AuthorizeTenant(ctx *AuthorizationContext, tenantRoles *TenantRoles, provider RuleProvider) error {
+	return defaultAuthorizer.AuthorizeTenant(ctx, tenantRoles, provider)
+}
+
+// AuthorizeTenant authorizes the context using roles which subject has in the context tenant.
+func (a *Authorizer) AuthorizeTenant(ctx *AuthorizationContext, tenantRoles *TenantRoles, provider RuleProvider) error {
+	if ctx.Tenant == "" || ctx.Subject == "" {
+		return ErrTenantMissing
+	}
+
+	return a.Authorize(ctx, tenantRoles.Roles(ctx.Tenant, ctx.Subject), provider)
+}
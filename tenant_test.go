@@ -0,0 +1,212 @@
+package rbac
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestTenantRoles(t *testing.T) {
+	adminRole := NewRole("admin", CreatePermission|ReadPermission|UpdatePermission|DeletePermission)
+	userRole := NewRole("user", SelfReadPermission)
+
+	tenantRoles := NewTenantRoles()
+	tenantRoles.Grant("tenant-a", "alice", adminRole, adminRole)
+	tenantRoles.Grant("tenant-b", "alice", userRole)
+
+	if roles := tenantRoles.Roles("tenant-a", "alice"); len(roles) != 1 || roles[0].Name != "admin" {
+		t.Errorf("Expected alice to be only admin in tenant-a, got %v", roles)
+	}
+	if roles := tenantRoles.Roles("tenant-b", "alice"); len(roles) != 1 || roles[0].Name != "user" {
+		t.Errorf("Expected alice to be only user in tenant-b, got %v", roles)
+	}
+	if roles := tenantRoles.Roles("tenant-c", "alice"); len(roles) != 0 {
+		t.Errorf("Expected alice to have no roles in tenant-c, got %v", roles)
+	}
+
+	// Modifying returned slice must not affect stored roles
+	roles := tenantRoles.Roles("tenant-a", "alice")
+	roles[0] = userRole
+	if tenantRoles.Roles("tenant-a", "alice")[0].Name != "admin" {
+		t.Error("Stored roles should not be affected by modification of returned slice")
+	}
+
+	tenantRoles.Revoke("tenant-a", "alice", "admin")
+	if roles := tenantRoles.Roles("tenant-a", "alice"); len(roles) != 0 {
+		t.Errorf("Expected no roles after revoke, got %v", roles)
+	}
+	if roles := tenantRoles.Roles("tenant-b", "alice"); len(roles) != 1 {
+		t.Errorf("Revoke in tenant-a should not affect tenant-b, got %v", roles)
+	}
+}
+
+func TestTenantRolesConcurrency(t *testing.T) {
+	role := NewRole("user", SelfReadPermission)
+	tenantRoles := NewTenantRoles()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tenantRoles.Grant("tenant", "alice", role)
+			tenantRoles.Roles("tenant", "alice")
+			tenantRoles.Revoke("tenant", "bob", "user")
+		}()
+	}
+	wg.Wait()
+
+	if roles := tenantRoles.Roles("tenant", "alice"); len(roles) != 1 {
+		t.Errorf("Expected 1 role, got %d", len(roles))
+	}
+}
+
+func TestAuthorizeTenantIsolation(t *testing.T) {
+	user := NewEntity("user")
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	cache := NewResource("cache")
+
+	adminRole := NewRole("admin", CreatePermission|ReadPermission|UpdatePermission|DeletePermission)
+	userRole := NewRole("user", SelfReadPermission|SelfDeletePermission)
+
+	tenantRoles := NewTenantRoles()
+	tenantRoles.Grant("tenant-a", "alice", adminRole)
+	tenantRoles.Grant("tenant-b", "alice", userRole)
+
+	tests := []struct {
+		name      string
+		tenant    string
+		subject   string
+		expectErr error
+	}{
+		{"admin in tenant-a", "tenant-a", "alice", nil},
+		{"user in tenant-b", "tenant-b", "alice", ErrInsufficientPermissions},
+		{"nothing in unknown tenant", "tenant-c", "alice", ErrInsufficientPermissions},
+		{"other subject in tenant-a", "tenant-a", "bob", ErrInsufficientPermissions},
+		{"missing tenant", "", "alice", ErrTenantMissing},
+		{"missing subject", "tenant-a", "", ErrTenantMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewAuthorizationContext(&user, deleteAction, cache)
+			ctx.Tenant = tt.tenant
+			ctx.Subject = tt.subject
+
+			err := AuthorizeTenant(&ctx, tenantRoles, nil)
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("Expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestTenantPolicy(t *testing.T) {
+	user := NewEntity("user")
+	deleteAction, _ := user.NewAction("delete", DeletePermission)
+	readAction, _ := user.NewAction("read", ReadPermission)
+	cache := NewResource("cache")
+
+	adminRole := NewRole("admin", CreatePermission|ReadPermission|UpdatePermission|DeletePermission)
+
+	deleteCtx := NewAuthorizationContext(&user, deleteAction, cache)
+	readCtx := NewAuthorizationContext(&user, readAction, cache)
+
+	base := NewActionGatePolicy()
+	base.AddRule(NewActionGateRule(&readCtx, DenyActionGateEffect, []Role{adminRole}))
+
+	overlayA := NewActionGatePolicy()
+	overlayA.AddRule(NewActionGateRule(&deleteCtx, DenyActionGateEffect, []Role{adminRole}))
+
+	schema := Schema{
+		ActionGatePolicy: base,
+		TenantPolicies:   map[string]ActionGatePolicy{"tenant-a": overlayA},
+	}
+
+	tests := []struct {
+		name      string
+		ctx       AuthorizationContext
+		tenant    string
+		expectErr error
+	}{
+		{"overlay denies delete in tenant-a", deleteCtx, "tenant-a", ErrActionDeniedByAGP},
+		{"overlay doesn't affect tenant-b", deleteCtx, "tenant-b", nil},
+		{"overlay doesn't affect context without tenant", deleteCtx, "", nil},
+		{"base policy applies in tenant-a", readCtx, "tenant-a", ErrActionDeniedByAGP},
+		{"base policy applies in tenant-b", readCtx, "tenant-b", ErrActionDeniedByAGP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			ctx.Tenant = tt.tenant
+
+			err := Authorize(&ctx, []Role{adminRole}, schema.Policy())
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("Expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	// Overlay rule takes precedence over base rule for the same context
+	overlayB := NewActionGatePolicy()
+	overlayB.AddRule(NewActionGateRule(&readCtx, AllowActionGateEffect, []Role{adminRole}))
+	schema.TenantPolicies["tenant-b"] = overlayB
+
+	ctx := readCtx
+	ctx.Tenant = "tenant-b"
+	if err := Authorize(&ctx, []Role{adminRole}, schema.Policy()); err != nil {
+		t.Errorf("Expected overlay allow rule to take precedence, got %v", err)
+	}
+}
+
+func TestLoadSchemaWithTenants(t *testing.T) {
+	config := `{
+		"id": "svc",
+		"roles": [{"name": "admin", "permissions": {"delete": true}}],
+		"resources": ["cache"],
+		"entities": [{"name": "user", "actions": [{"name": "delete", "required-permissions": {"delete": true}}]}],
+		"tenants": [
+			{"id": "tenant-a", "action-gate-policy": [{"for": ["user"], "having": ["admin"], "apply": "deny", "doing": ["delete"], "on": "cache"}]}
+		]
+	}`
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	if _, ok := schema.TenantPolicies["tenant-a"]; !ok {
+		t.Fatal("Tenant policy should be loaded")
+	}
+
+	ctx := NewAuthorizationContext(&schema.Entities[0], "delete", &schema.Resources[0])
+	ctx.Tenant = "tenant-a"
+	if err := Authorize(&ctx, schema.Roles, schema.Policy()); !errors.Is(err, ErrActionDeniedByAGP) {
+		t.Errorf("Expected ErrActionDeniedByAGP in tenant-a, got %v", err)
+	}
+
+	ctx.Tenant = "tenant-b"
+	if err := Authorize(&ctx, schema.Roles, schema.Policy()); err != nil {
+		t.Errorf("Expected no error in tenant-b, got %v", err)
+	}
+
+	invalid := `{
+		"id": "svc",
+		"resources": ["cache"],
+		"tenants": [{"id": "tenant-a", "action-gate-policy": [{"for": ["user"], "apply": "deny", "doing": ["delete"], "on": "storage"}]}]
+	}`
+	if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSchema(path); err == nil {
+		t.Error("Expected error for tenant policy referencing unknown resource")
+	}
+}
@@ -20,7 +20,7 @@ func validateDefaultRoles(roles []Role, defaultRoles []Role) error {
 	return nil
 }
 
-func validateAGP(schema *Schema) error {
+func validateAGP(schema *Schema, agp ActionGatePolicy) error {
 	// Create lookup maps for O(1) validation
 	entityMap := make(map[string]bool)
 	for _, entity := range schema.Entities {
@@ -37,7 +37,7 @@ func validateAGP(schema *Schema) error {
 		roleMap[role.Name] = true
 	}
 
-	for ruleName, rule := range schema.ActionGatePolicy.rules {
+	for ruleName, rule := range agp.rules {
 		if err := rule.Effect.Validate(); err != nil {
 			return fmt.Errorf("Invalid Action Gate Policy rule %s in the %s schema - %s", ruleName, schema.ID, err.Error())
 		}
@@ -76,13 +76,67 @@ func validateAGP(schema *Schema) error {
 	return nil
 }
 
+func validateUsersetRewrite(t *RelationType, relation string, rewrite UsersetRewrite) error {
+	switch rewrite.kind {
+	case computedUsersetRewrite:
+		if !t.HasRelation(rewrite.relation) {
+			return fmt.Errorf(
+				"Invalid relation %s#%s - computed userset references undefined relation %s",
+				t.name, relation, rewrite.relation,
+			)
+		}
+	case tupleToUsersetRewrite:
+		if !t.HasRelation(rewrite.tupleset) {
+			return fmt.Errorf(
+				"Invalid relation %s#%s - tuple to userset references undefined tupleset %s",
+				t.name, relation, rewrite.tupleset,
+			)
+		}
+	case unionRewrite:
+		if len(rewrite.children) == 0 {
+			return fmt.Errorf("Invalid relation %s#%s - union is empty", t.name, relation)
+		}
+		for _, child := range rewrite.children {
+			if err := validateUsersetRewrite(t, relation, child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRelations(schema *Schema) error {
+	if schema.Relations == nil {
+		return nil
+	}
+
+	for _, t := range schema.Relations.types {
+		for relation, rewrite := range t.relations {
+			if err := validateUsersetRewrite(t, relation, rewrite); err != nil {
+				return fmt.Errorf("%s in the %s schema", err.Error(), schema.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
 func ValidateSchema(schema *Schema) error {
 	Debug.Log("Validating schema '" + schema.ID + "' (" + schema.ID + ")...")
 
 	if err := validateDefaultRoles(schema.Roles, schema.DefaultRoles); err != nil {
 		return err
 	}
-	if err := validateAGP(schema); err != nil {
+	if err := validateAGP(schema, schema.ActionGatePolicy); err != nil {
+		return err
+	}
+	for tenant, agp := range schema.TenantPolicies {
+		if err := validateAGP(schema, agp); err != nil {
+			return fmt.Errorf("Invalid policy of the %s tenant - %s", tenant, err.Error())
+		}
+	}
+	if err := validateRelations(schema); err != nil {
 		return err
 	}
 